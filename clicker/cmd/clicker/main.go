@@ -3,7 +3,6 @@ package main
 import (
 	"bufio"
 	"context"
-	"encoding/base64"
 	"fmt"
 	"os"
 	"time"
@@ -222,7 +221,7 @@ func main() {
 			client := bidi.NewClient(conn)
 			client.SetVerbose(true)
 
-			status, err := client.SessionStatus()
+			status, err := client.Status()
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
@@ -263,7 +262,7 @@ func main() {
 				client := bidi.NewClient(conn)
 
 				fmt.Printf("Navigating to %s...\n", url)
-				result, err := client.Navigate("", url)
+				result, err := client.Navigate("", url, bidi.ReadinessComplete)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Error navigating: %v\n", err)
 					os.Exit(1)
@@ -306,7 +305,7 @@ func main() {
 				client := bidi.NewClient(conn)
 
 				fmt.Printf("Navigating to %s...\n", url)
-				_, err = client.Navigate("", url)
+				_, err = client.Navigate("", url, bidi.ReadinessComplete)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Error navigating: %v\n", err)
 					os.Exit(1)
@@ -315,19 +314,12 @@ func main() {
 				doWaitOpen()
 
 				fmt.Println("Capturing screenshot...")
-				base64Data, err := client.CaptureScreenshot("")
+				pngData, err := client.CaptureScreenshot("", bidi.ScreenshotOpts{})
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Error capturing screenshot: %v\n", err)
 					os.Exit(1)
 				}
 
-				// Decode base64 to PNG bytes
-				pngData, err := base64.StdEncoding.DecodeString(base64Data)
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error decoding screenshot: %v\n", err)
-					os.Exit(1)
-				}
-
 				// Save to file
 				if err := os.WriteFile(output, pngData, 0644); err != nil {
 					fmt.Fprintf(os.Stderr, "Error saving screenshot: %v\n", err)
@@ -371,7 +363,7 @@ func main() {
 				client := bidi.NewClient(conn)
 
 				fmt.Printf("Navigating to %s...\n", url)
-				_, err = client.Navigate("", url)
+				_, err = client.Navigate("", url, bidi.ReadinessComplete)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Error navigating: %v\n", err)
 					os.Exit(1)
@@ -421,7 +413,7 @@ func main() {
 				client := bidi.NewClient(conn)
 
 				fmt.Printf("Navigating to %s...\n", url)
-				_, err = client.Navigate("", url)
+				_, err = client.Navigate("", url, bidi.ReadinessComplete)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Error navigating: %v\n", err)
 					os.Exit(1)
@@ -477,7 +469,7 @@ func main() {
 				client := bidi.NewClient(conn)
 
 				fmt.Printf("Navigating to %s...\n", url)
-				_, err = client.Navigate("", url)
+				_, err = client.Navigate("", url, bidi.ReadinessComplete)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Error navigating: %v\n", err)
 					os.Exit(1)
@@ -494,7 +486,7 @@ func main() {
 				}
 
 				fmt.Printf("Clicking element: %s\n", selector)
-				err = client.ClickElement("", selector)
+				err = client.Click("", selector)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Error clicking: %v\n", err)
 					os.Exit(1)
@@ -554,7 +546,7 @@ func main() {
 				client := bidi.NewClient(conn)
 
 				fmt.Printf("Navigating to %s...\n", url)
-				_, err = client.Navigate("", url)
+				_, err = client.Navigate("", url, bidi.ReadinessComplete)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Error navigating: %v\n", err)
 					os.Exit(1)
@@ -571,7 +563,7 @@ func main() {
 				}
 
 				fmt.Printf("Typing into element: %s\n", selector)
-				err = client.TypeIntoElement("", selector, text)
+				err = client.Type("", selector, text)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Error typing: %v\n", err)
 					os.Exit(1)
@@ -627,7 +619,7 @@ func main() {
 				client := bidi.NewClient(conn)
 
 				fmt.Printf("Navigating to %s...\n", url)
-				_, err = client.Navigate("", url)
+				_, err = client.Navigate("", url, bidi.ReadinessComplete)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Error navigating: %v\n", err)
 					os.Exit(1)
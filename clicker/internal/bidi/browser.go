@@ -0,0 +1,136 @@
+package bidi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	errs "github.com/vibium/clicker/internal/errors"
+)
+
+// CreateUserContextResult represents the result of browser.createUserContext.
+type CreateUserContextResult struct {
+	UserContext string `json:"userContext"`
+}
+
+// CreateUserContext creates a user context, an isolated profile with its own
+// cookies and storage, and returns its id for use in CreateContextOpts.
+func (c *Client) CreateUserContext() (string, error) {
+	msg, err := c.SendCommand("browser.createUserContext", map[string]interface{}{})
+	if err != nil {
+		return "", err
+	}
+
+	var result CreateUserContextResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return "", fmt.Errorf("failed to parse browser.createUserContext result: %w", err)
+	}
+
+	return result.UserContext, nil
+}
+
+// DefaultUserContext is the id of the browser's default user context.
+const DefaultUserContext = "default"
+
+// RemoveUserContext closes all contexts in a user context and deletes it.
+// The default user context cannot be removed.
+func (c *Client) RemoveUserContext(userContext string) error {
+	if userContext == "" {
+		return fmt.Errorf("user context id is required")
+	}
+	if userContext == DefaultUserContext {
+		return fmt.Errorf("cannot remove the default user context")
+	}
+
+	params := map[string]interface{}{
+		"userContext": userContext,
+	}
+
+	_, err := c.SendCommand("browser.removeUserContext", params)
+	return err
+}
+
+// UserContextInfo describes a user context.
+type UserContextInfo struct {
+	UserContext string `json:"userContext"`
+}
+
+// GetUserContextsResult represents the result of browser.getUserContexts.
+type GetUserContextsResult struct {
+	UserContexts []UserContextInfo `json:"userContexts"`
+}
+
+// GetUserContexts returns all user contexts, including the default one.
+func (c *Client) GetUserContexts() ([]UserContextInfo, error) {
+	msg, err := c.SendCommand("browser.getUserContexts", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	var result GetUserContextsResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse browser.getUserContexts result: %w", err)
+	}
+
+	return result.UserContexts, nil
+}
+
+// BrowserClose closes the browser, ending every session, and then closes the
+// client. The connection is expected to drop, so no reconnect is attempted
+// and losing the connection before the response arrives is not an error.
+func (c *Client) BrowserClose() error {
+	c.connMu.Lock()
+	c.browserGone = true
+	c.connMu.Unlock()
+
+	_, err := c.SendCommand("browser.close", map[string]interface{}{})
+
+	var bidiErr *errs.BiDiError
+	if errors.As(err, &bidiErr) {
+		c.connMu.Lock()
+		c.browserGone = false
+		c.connMu.Unlock()
+		return err
+	}
+
+	return c.Close()
+}
+
+// SetDownloadBehavior saves downloads to dir, or denies them if dir is
+// empty. If userContexts is empty, it applies to all user contexts.
+func (c *Client) SetDownloadBehavior(dir string, userContexts []string) error {
+	behavior := map[string]interface{}{"type": "denied"}
+	if dir != "" {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("download directory must be an absolute path: %s", dir)
+		}
+		behavior = map[string]interface{}{
+			"type":              "allowed",
+			"destinationFolder": dir,
+		}
+	}
+
+	params := map[string]interface{}{
+		"downloadBehavior": behavior,
+	}
+	if len(userContexts) > 0 {
+		params["userContexts"] = userContexts
+	}
+
+	_, err := c.SendCommand("browser.setDownloadBehavior", params)
+	return err
+}
+
+// ResetDownloadBehavior restores the browser's default download behavior.
+func (c *Client) ResetDownloadBehavior(userContexts []string) error {
+	params := map[string]interface{}{
+		"downloadBehavior": nil,
+	}
+	if len(userContexts) > 0 {
+		params["userContexts"] = userContexts
+	}
+
+	_, err := c.SendCommand("browser.setDownloadBehavior", params)
+	return err
+}
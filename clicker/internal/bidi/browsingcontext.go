@@ -1,26 +1,65 @@
 package bidi
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	errs "github.com/vibium/clicker/internal/errors"
 )
 
 // BrowsingContextInfo represents a browsing context in the tree.
 type BrowsingContextInfo struct {
-	Context  string                `json:"context"`
-	URL      string                `json:"url"`
-	Children []BrowsingContextInfo `json:"children,omitempty"`
-	Parent   string                `json:"parent,omitempty"`
+	Context     string                `json:"context"`
+	URL         string                `json:"url"`
+	Children    []BrowsingContextInfo `json:"children,omitempty"`
+	Parent      string                `json:"parent,omitempty"`
+	UserContext string                `json:"userContext,omitempty"`
 }
 
+// ContextInfo is the browsing context info delivered with context events.
+type ContextInfo = BrowsingContextInfo
+
 // GetTreeResult represents the result of browsingContext.getTree.
 type GetTreeResult struct {
 	Contexts []BrowsingContextInfo `json:"contexts"`
 }
 
-// GetTree returns the tree of browsing contexts.
+// GetTree returns the full tree of browsing contexts.
 func (c *Client) GetTree() (*GetTreeResult, error) {
-	msg, err := c.SendCommand("browsingContext.getTree", map[string]interface{}{})
+	return c.GetTreeWithOpts(GetTreeOpts{})
+}
+
+// GetTreeDepth returns the tree of browsing contexts down to maxDepth levels
+// of children. A maxDepth of 0 returns only top-level contexts.
+func (c *Client) GetTreeDepth(maxDepth int) (*GetTreeResult, error) {
+	return c.GetTreeWithOpts(GetTreeOpts{MaxDepth: &maxDepth})
+}
+
+// GetTreeOpts configures GetTreeWithOpts.
+type GetTreeOpts struct {
+	MaxDepth *int   // nil means unlimited
+	Root     string // optional context to return the subtree of
+}
+
+// GetTreeWithOpts returns the tree of browsing contexts with depth and root options.
+func (c *Client) GetTreeWithOpts(opts GetTreeOpts) (*GetTreeResult, error) {
+	params := map[string]interface{}{}
+	if opts.MaxDepth != nil {
+		if *opts.MaxDepth < 0 {
+			return nil, fmt.Errorf("max depth must not be negative, got %d", *opts.MaxDepth)
+		}
+		params["maxDepth"] = *opts.MaxDepth
+	}
+	if opts.Root != "" {
+		params["root"] = opts.Root
+	}
+
+	msg, err := c.SendCommand("browsingContext.getTree", params)
 	if err != nil {
 		return nil, err
 	}
@@ -45,9 +84,19 @@ type NavigateResult struct {
 	URL        string `json:"url"`
 }
 
-// Navigate navigates a browsing context to a URL.
+// ReadinessState controls how long navigation commands wait before returning.
+type ReadinessState string
+
+const (
+	ReadinessNone        ReadinessState = "none"        // return immediately
+	ReadinessInteractive ReadinessState = "interactive" // wait for DOMContentLoaded
+	ReadinessComplete    ReadinessState = "complete"    // wait for the load event
+)
+
+// Navigate navigates a browsing context to a URL and waits for the given
+// readiness state. An empty wait defaults to ReadinessComplete.
 // If context is empty, it uses the first available context.
-func (c *Client) Navigate(context, url string) (*NavigateResult, error) {
+func (c *Client) Navigate(context, url string, wait ReadinessState) (*NavigateResult, error) {
 	// If no context provided, get the first one from the tree
 	if context == "" {
 		tree, err := c.GetTree()
@@ -60,15 +109,19 @@ func (c *Client) Navigate(context, url string) (*NavigateResult, error) {
 		context = tree.Contexts[0].Context
 	}
 
+	if wait == "" {
+		wait = ReadinessComplete
+	}
+
 	params := map[string]interface{}{
 		"context": context,
 		"url":     url,
-		"wait":    "complete", // Wait for page load to complete
+		"wait":    string(wait),
 	}
 
 	msg, err := c.SendCommand("browsingContext.navigate", params)
 	if err != nil {
-		return nil, err
+		return nil, wrapContextError(context, err)
 	}
 
 	var result NavigateResult
@@ -79,6 +132,194 @@ func (c *Client) Navigate(context, url string) (*NavigateResult, error) {
 	return &result, nil
 }
 
+// Reload reloads a browsing context and waits for the given readiness state.
+// If ignoreCache is true, the reload bypasses the HTTP cache.
+// If context is empty, it uses the first available context.
+func (c *Client) Reload(context string, ignoreCache bool, wait ReadinessState) (*NavigateResult, error) {
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return nil, fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	if wait == "" {
+		wait = ReadinessComplete
+	}
+
+	params := map[string]interface{}{
+		"context": context,
+		"wait":    string(wait),
+	}
+	if ignoreCache {
+		params["ignoreCache"] = true
+	}
+
+	msg, err := c.SendCommand("browsingContext.reload", params)
+	if err != nil {
+		return nil, wrapContextError(context, err)
+	}
+
+	var result NavigateResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse browsingContext.reload result: %w", err)
+	}
+
+	return &result, nil
+}
+
+// wrapContextError adds the context id to "no such frame" errors.
+func wrapContextError(context string, err error) error {
+	var bidiErr *errs.BiDiError
+	if errors.As(err, &bidiErr) && bidiErr.Code == "no such frame" {
+		return fmt.Errorf("unknown browsing context %s: %w", context, err)
+	}
+	return err
+}
+
+// OnContextCreated subscribes to browsingContext.contextCreated events, fired
+// when a tab, window or iframe is created.
+func (c *Client) OnContextCreated(handler func(ContextInfo)) error {
+	return c.onContextEvent("browsingContext.contextCreated", handler)
+}
+
+// OnContextDestroyed subscribes to browsingContext.contextDestroyed events,
+// fired when a tab, window or iframe is closed.
+func (c *Client) OnContextDestroyed(handler func(ContextInfo)) error {
+	return c.onContextEvent("browsingContext.contextDestroyed", handler)
+}
+
+// onContextEvent registers a handler for an event carrying a ContextInfo.
+func (c *Client) onContextEvent(method string, handler func(ContextInfo)) error {
+	return c.subscribeHandler(method, nil, func(params json.RawMessage) {
+		var info ContextInfo
+		if err := json.Unmarshal(params, &info); err != nil {
+			return
+		}
+		handler(info)
+	})
+}
+
+// NavigationEvent is delivered with navigation lifecycle events.
+type NavigationEvent struct {
+	Context    string `json:"context"`
+	Navigation string `json:"navigation"`
+	Timestamp  int64  `json:"timestamp"` // milliseconds since the epoch
+	URL        string `json:"url"`
+}
+
+// OnNavigationStarted subscribes to browsingContext.navigationStarted events.
+// If context is non-empty, only events for that context are delivered.
+func (c *Client) OnNavigationStarted(context string, handler func(NavigationEvent)) error {
+	return c.onNavigationEvent("browsingContext.navigationStarted", context, handler)
+}
+
+// OnDomContentLoaded subscribes to browsingContext.domContentLoaded events.
+// If context is non-empty, only events for that context are delivered.
+func (c *Client) OnDomContentLoaded(context string, handler func(NavigationEvent)) error {
+	return c.onNavigationEvent("browsingContext.domContentLoaded", context, handler)
+}
+
+// OnLoad subscribes to browsingContext.load events.
+// If context is non-empty, only events for that context are delivered.
+func (c *Client) OnLoad(context string, handler func(NavigationEvent)) error {
+	return c.onNavigationEvent("browsingContext.load", context, handler)
+}
+
+// onNavigationEvent registers a handler for an event carrying a NavigationEvent,
+// subscribing only for the given context when one is supplied.
+func (c *Client) onNavigationEvent(method, context string, handler func(NavigationEvent)) error {
+	var contexts []string
+	if context != "" {
+		contexts = []string{context}
+	}
+
+	return c.subscribeHandler(method, contexts, func(params json.RawMessage) {
+		var event NavigationEvent
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+		if context != "" && event.Context != context {
+			return
+		}
+		handler(event)
+	})
+}
+
+// CreateContextOpts configures CreateContext.
+type CreateContextOpts struct {
+	Type             string // "tab" (default) or "window"
+	ReferenceContext string // optional context to open next to
+	UserContext      string // optional user context (profile) id
+	Background       bool   // open without bringing it to the foreground
+}
+
+// CreateContextResult represents the result of browsingContext.create.
+type CreateContextResult struct {
+	Context string `json:"context"`
+}
+
+// CreateContext opens a new tab or window and returns its context id.
+func (c *Client) CreateContext(opts CreateContextOpts) (string, error) {
+	contextType := opts.Type
+	if contextType == "" {
+		contextType = "tab"
+	}
+	if contextType != "tab" && contextType != "window" {
+		return "", fmt.Errorf("invalid context type: %s (expected \"tab\" or \"window\")", contextType)
+	}
+
+	params := map[string]interface{}{
+		"type": contextType,
+	}
+	if opts.ReferenceContext != "" {
+		params["referenceContext"] = opts.ReferenceContext
+	}
+	if opts.UserContext != "" {
+		params["userContext"] = opts.UserContext
+	}
+	if opts.Background {
+		params["background"] = true
+	}
+
+	msg, err := c.SendCommand("browsingContext.create", params)
+	if err != nil {
+		return "", err
+	}
+
+	var result CreateContextResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return "", fmt.Errorf("failed to parse browsingContext.create result: %w", err)
+	}
+
+	return result.Context, nil
+}
+
+// CloseContext closes a browsing context. If promptUnload is true, beforeunload
+// handlers are allowed to run and may show a prompt. Closing the last
+// top-level context may close the browser. Closing a context that no longer
+// exists returns the browser's error.
+func (c *Client) CloseContext(context string, promptUnload bool) error {
+	if context == "" {
+		return fmt.Errorf("context is required")
+	}
+
+	params := map[string]interface{}{
+		"context": context,
+	}
+	if promptUnload {
+		params["promptUnload"] = true
+	}
+
+	_, err := c.SendCommand("browsingContext.close", params)
+	return err
+}
+
 // GetCurrentURL returns the URL of the first browsing context.
 func (c *Client) GetCurrentURL() (string, error) {
 	tree, err := c.GetTree()
@@ -93,21 +334,47 @@ func (c *Client) GetCurrentURL() (string, error) {
 
 // CaptureScreenshotResult represents the result of browsingContext.captureScreenshot.
 type CaptureScreenshotResult struct {
-	Data string `json:"data"` // Base64-encoded PNG
+	Data string `json:"data"` // Base64-encoded image
+}
+
+// ClipRectangle is a screenshot clip region in CSS pixels.
+type ClipRectangle struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// ScreenshotOpts configures CaptureScreenshot.
+type ScreenshotOpts struct {
+	// Origin is "viewport" (default) or "document" for a full-page capture.
+	Origin string
+
+	// Clip restricts the capture to a region, relative to Origin.
+	Clip *ClipRectangle
+
+	// Element restricts the capture to a node's bounding box.
+	// It must carry a sharedId and cannot be combined with Clip.
+	Element *RemoteValue
+
+	// Format is "png" (default) or "jpeg".
+	Format string
+
+	// Quality is the jpeg quality between 0 and 1. Only valid for jpeg.
+	Quality *float64
 }
 
-// CaptureScreenshot captures a screenshot of the viewport.
+// CaptureScreenshot captures a screenshot and returns the decoded image bytes.
 // If context is empty, it uses the first available context.
-// Returns base64-encoded PNG data.
-func (c *Client) CaptureScreenshot(context string) (string, error) {
+func (c *Client) CaptureScreenshot(context string, opts ScreenshotOpts) ([]byte, error) {
 	// If no context provided, get the first one from the tree
 	if context == "" {
 		tree, err := c.GetTree()
 		if err != nil {
-			return "", fmt.Errorf("failed to get browsing context: %w", err)
+			return nil, fmt.Errorf("failed to get browsing context: %w", err)
 		}
 		if len(tree.Contexts) == 0 {
-			return "", fmt.Errorf("no browsing contexts available")
+			return nil, fmt.Errorf("no browsing contexts available")
 		}
 		context = tree.Contexts[0].Context
 	}
@@ -116,15 +383,426 @@ func (c *Client) CaptureScreenshot(context string) (string, error) {
 		"context": context,
 	}
 
+	switch opts.Origin {
+	case "":
+	case "viewport", "document":
+		params["origin"] = opts.Origin
+	default:
+		return nil, fmt.Errorf("invalid screenshot origin: %s (expected \"viewport\" or \"document\")", opts.Origin)
+	}
+
+	switch opts.Format {
+	case "", "png":
+		if opts.Quality != nil {
+			return nil, fmt.Errorf("screenshot quality is only supported for jpeg")
+		}
+		if opts.Format == "png" {
+			params["format"] = map[string]interface{}{"type": "image/png"}
+		}
+	case "jpeg":
+		format := map[string]interface{}{"type": "image/jpeg"}
+		if opts.Quality != nil {
+			if *opts.Quality < 0 || *opts.Quality > 1 {
+				return nil, fmt.Errorf("screenshot quality must be between 0 and 1, got %v", *opts.Quality)
+			}
+			format["quality"] = *opts.Quality
+		}
+		params["format"] = format
+	default:
+		return nil, fmt.Errorf("invalid screenshot format: %s (expected \"png\" or \"jpeg\")", opts.Format)
+	}
+
+	if opts.Clip != nil && opts.Element != nil {
+		return nil, fmt.Errorf("screenshot clip and element are mutually exclusive")
+	}
+	if opts.Element != nil {
+		if opts.Element.SharedID == "" {
+			return nil, fmt.Errorf("screenshot element has no sharedId")
+		}
+		params["clip"] = map[string]interface{}{
+			"type":    "element",
+			"element": map[string]interface{}{"sharedId": opts.Element.SharedID},
+		}
+	}
+	if opts.Clip != nil {
+		params["clip"] = map[string]interface{}{
+			"type":   "box",
+			"x":      opts.Clip.X,
+			"y":      opts.Clip.Y,
+			"width":  opts.Clip.Width,
+			"height": opts.Clip.Height,
+		}
+	}
+
 	msg, err := c.SendCommand("browsingContext.captureScreenshot", params)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	var result CaptureScreenshotResult
 	if err := json.Unmarshal(msg.Result, &result); err != nil {
-		return "", fmt.Errorf("failed to parse browsingContext.captureScreenshot result: %w", err)
+		return nil, fmt.Errorf("failed to parse browsingContext.captureScreenshot result: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(result.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+
+	return data, nil
+}
+
+// CaptureElementScreenshot scrolls a node into view and captures a screenshot
+// cropped to its bounding box.
+// If context is empty, it uses the first available context.
+func (c *Client) CaptureElementScreenshot(context string, node *RemoteValue) ([]byte, error) {
+	if node == nil || node.SharedID == "" {
+		return nil, fmt.Errorf("element has no sharedId")
 	}
 
-	return result.Data, nil
+	// The element clip is relative to the viewport, so bring it into view first
+	if err := c.scrollIntoView(context, node); err != nil {
+		return nil, err
+	}
+
+	return c.CaptureScreenshot(context, ScreenshotOpts{Element: node})
+}
+
+// ScreenshotToFile captures a screenshot and writes it to path, creating
+// parent directories as needed. If opts.Format is empty, it is inferred from
+// the file extension: ".jpg" and ".jpeg" capture jpeg, anything else png.
+// If context is empty, it uses the first available context.
+func (c *Client) ScreenshotToFile(context, path string, opts ScreenshotOpts) error {
+	if opts.Format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".jpg", ".jpeg":
+			opts.Format = "jpeg"
+		default:
+			opts.Format = "png"
+		}
+	}
+
+	data, err := c.CaptureScreenshot(context, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write screenshot to %s: %w", path, err)
+	}
+	return nil
+}
+
+// PrintMargin is a page margin in centimeters. Nil sides use the 1cm
+// default, so a side can be set to zero without resetting the others.
+type PrintMargin struct {
+	Top    *float64
+	Bottom *float64
+	Left   *float64
+	Right  *float64
+}
+
+// PrintPage is a paper size in centimeters.
+type PrintPage struct {
+	Width  float64
+	Height float64
+}
+
+// PrintOpts configures Print. Zero values use the spec defaults:
+// no background, portrait, 1cm margins, US Letter, scale 1 and shrink to fit.
+type PrintOpts struct {
+	Background  bool
+	Landscape   bool
+	Margin      *PrintMargin
+	Page        *PrintPage
+	Scale       float64 // between 0.1 and 2; 0 means 1
+	PageRanges  string  // e.g. "1-3,5"; empty prints all pages
+	ShrinkToFit *bool   // nil means true
+}
+
+// PrintResult represents the result of browsingContext.print.
+type PrintResult struct {
+	Data string `json:"data"` // Base64-encoded PDF
+}
+
+// Print renders the page to PDF and returns the decoded bytes.
+// If context is empty, it uses the first available context.
+func (c *Client) Print(context string, opts PrintOpts) ([]byte, error) {
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return nil, fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	params := map[string]interface{}{
+		"context": context,
+	}
+	if opts.Background {
+		params["background"] = true
+	}
+	if opts.Landscape {
+		params["orientation"] = "landscape"
+	}
+	if opts.Margin != nil {
+		margin := map[string]interface{}{}
+		sides := []struct {
+			name  string
+			value *float64
+		}{
+			{"top", opts.Margin.Top},
+			{"bottom", opts.Margin.Bottom},
+			{"left", opts.Margin.Left},
+			{"right", opts.Margin.Right},
+		}
+		for _, side := range sides {
+			if side.value != nil {
+				margin[side.name] = *side.value
+			}
+		}
+		params["margin"] = margin
+	}
+	if opts.Page != nil {
+		params["page"] = map[string]interface{}{
+			"width":  opts.Page.Width,
+			"height": opts.Page.Height,
+		}
+	}
+	if opts.Scale != 0 {
+		if opts.Scale < 0.1 || opts.Scale > 2 {
+			return nil, fmt.Errorf("print scale must be between 0.1 and 2, got %v", opts.Scale)
+		}
+		params["scale"] = opts.Scale
+	}
+	if opts.PageRanges != "" {
+		ranges := strings.Split(opts.PageRanges, ",")
+		for i, r := range ranges {
+			ranges[i] = strings.TrimSpace(r)
+		}
+		params["pageRanges"] = ranges
+	}
+	if opts.ShrinkToFit != nil {
+		params["shrinkToFit"] = *opts.ShrinkToFit
+	}
+
+	msg, err := c.SendCommand("browsingContext.print", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result PrintResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse browsingContext.print result: %w", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(result.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PDF: %w", err)
+	}
+
+	return data, nil
+}
+
+// SetViewport sets the viewport size of a top-level context in CSS pixels.
+// A zero width and height resets the viewport to the browser default.
+// A zero devicePixelRatio leaves the current ratio unchanged.
+// If context is empty, it uses the first available context.
+func (c *Client) SetViewport(context string, width, height int, devicePixelRatio float64) error {
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	if width < 0 || height < 0 {
+		return fmt.Errorf("viewport size must not be negative, got %dx%d", width, height)
+	}
+	if devicePixelRatio < 0 {
+		return fmt.Errorf("device pixel ratio must not be negative, got %v", devicePixelRatio)
+	}
+
+	params := map[string]interface{}{
+		"context": context,
+	}
+	if width == 0 && height == 0 {
+		params["viewport"] = nil
+	} else {
+		params["viewport"] = map[string]interface{}{
+			"width":  width,
+			"height": height,
+		}
+	}
+	if devicePixelRatio != 0 {
+		params["devicePixelRatio"] = devicePixelRatio
+	}
+
+	_, err := c.SendCommand("browsingContext.setViewport", params)
+	return err
+}
+
+// HandleUserPrompt closes an open alert, confirm, prompt or beforeunload dialog.
+// For confirms, accept chooses OK over Cancel; for prompts, userText is the
+// text entered before accepting.
+// If context is empty, it uses the first available context.
+func (c *Client) HandleUserPrompt(context string, accept bool, userText string) error {
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	params := map[string]interface{}{
+		"context": context,
+		"accept":  accept,
+	}
+	if userText != "" {
+		params["userText"] = userText
+	}
+
+	_, err := c.SendCommand("browsingContext.handleUserPrompt", params)
+	return err
+}
+
+// UserPromptEvent is delivered when a dialog opens.
+type UserPromptEvent struct {
+	Context      string `json:"context"`
+	Type         string `json:"type"` // "alert", "confirm", "prompt" or "beforeunload"
+	Message      string `json:"message"`
+	DefaultValue string `json:"defaultValue,omitempty"`
+}
+
+// OnUserPromptOpened subscribes to browsingContext.userPromptOpened events.
+func (c *Client) OnUserPromptOpened(handler func(UserPromptEvent)) error {
+	return c.subscribeHandler("browsingContext.userPromptOpened", nil, func(params json.RawMessage) {
+		var event UserPromptEvent
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+		handler(event)
+	})
+}
+
+// TraverseHistory moves through session history by delta entries; negative
+// goes back, positive goes forward. The browser returns an error if delta
+// goes past either end of the history.
+// If context is empty, it uses the first available context.
+func (c *Client) TraverseHistory(context string, delta int) error {
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	params := map[string]interface{}{
+		"context": context,
+		"delta":   delta,
+	}
+
+	_, err := c.SendCommand("browsingContext.traverseHistory", params)
+	return err
+}
+
+// Back navigates one entry back in session history.
+func (c *Client) Back(context string) error {
+	return c.TraverseHistory(context, -1)
+}
+
+// Forward navigates one entry forward in session history.
+func (c *Client) Forward(context string) error {
+	return c.TraverseHistory(context, 1)
+}
+
+// Activate brings a top-level context to the foreground and focuses it.
+// Only top-level contexts can be activated; iframes return an error.
+func (c *Client) Activate(context string) error {
+	if context == "" {
+		return fmt.Errorf("context is required")
+	}
+
+	maxDepth := 0
+	tree, err := c.GetTreeWithOpts(GetTreeOpts{MaxDepth: &maxDepth, Root: context})
+	if err != nil {
+		return wrapContextError(context, err)
+	}
+	if len(tree.Contexts) > 0 && tree.Contexts[0].Parent != "" {
+		return fmt.Errorf("cannot activate context %s: not a top-level context", context)
+	}
+
+	params := map[string]interface{}{
+		"context": context,
+	}
+
+	_, err = c.SendCommand("browsingContext.activate", params)
+	return err
+}
+
+// DownloadStartedEvent is delivered when a download is about to begin.
+type DownloadStartedEvent struct {
+	Context           string `json:"context"`
+	Navigation        string `json:"navigation"`
+	Timestamp         int64  `json:"timestamp"`
+	URL               string `json:"url"`
+	SuggestedFilename string `json:"suggestedFilename"`
+}
+
+// DownloadCompletedEvent is delivered when a download finishes. Status is
+// "complete" or "canceled"; Filepath is the saved file for complete
+// downloads when the browser reports it. BiDi does not report the size, so
+// stat the file to get it.
+type DownloadCompletedEvent struct {
+	Context    string `json:"context"`
+	Navigation string `json:"navigation"`
+	Timestamp  int64  `json:"timestamp"`
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	Filepath   string `json:"filepath,omitempty"`
+}
+
+// OnDownloadStarted subscribes to browsingContext.downloadWillBegin events.
+func (c *Client) OnDownloadStarted(handler func(DownloadStartedEvent)) error {
+	return c.subscribeHandler("browsingContext.downloadWillBegin", nil, func(params json.RawMessage) {
+		var event DownloadStartedEvent
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+		handler(event)
+	})
+}
+
+// OnDownloadCompleted subscribes to browsingContext.downloadEnd events.
+// Browsers that do not support it reject the subscription.
+func (c *Client) OnDownloadCompleted(handler func(DownloadCompletedEvent)) error {
+	return c.subscribeHandler("browsingContext.downloadEnd", nil, func(params json.RawMessage) {
+		var event DownloadCompletedEvent
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+		handler(event)
+	})
 }
@@ -1,8 +1,11 @@
 package bidi
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 	errs "github.com/vibium/clicker/internal/errors"
@@ -14,18 +17,48 @@ const maxMessageSize = 10 * 1024 * 1024
 
 // Connection represents a WebSocket connection.
 type Connection struct {
+	url    string
+	opts   DialOptions
 	conn   *websocket.Conn
 	mu     sync.Mutex
 	closed bool
+	done   chan struct{} // closed by Close to stop the keepalive
+
+	// armDeadline sets the first keepalive read deadline when reading
+	// starts, so idle time before the first Receive doesn't count.
+	armDeadline sync.Once
+}
+
+// DialOptions configures ConnectWithOptions.
+type DialOptions struct {
+	// TLSConfig is used for wss:// URLs, e.g. to trust a custom CA or present
+	// a client certificate. Nil uses the system defaults.
+	TLSConfig *tls.Config
+
+	// Header is sent with the WebSocket handshake, e.g. for authorization.
+	Header http.Header
+
+	// KeepAlive sends a WebSocket ping at this interval, for proxies that
+	// drop idle connections. If no pong or other frame arrives within two
+	// intervals, Receive fails as if the connection had dropped, which lets
+	// a client with reconnect enabled reconnect. Zero disables pings.
+	KeepAlive time.Duration
 }
 
 // Connect establishes a WebSocket connection to the given URL.
 func Connect(url string) (*Connection, error) {
+	return ConnectWithOptions(url, DialOptions{})
+}
+
+// ConnectWithOptions establishes a WebSocket connection to the given URL
+// using the given TLS settings and handshake headers.
+func ConnectWithOptions(url string, opts DialOptions) (*Connection, error) {
 	dialer := websocket.Dialer{
 		ReadBufferSize:  maxMessageSize,
 		WriteBufferSize: maxMessageSize,
+		TLSClientConfig: opts.TLSConfig,
 	}
-	conn, _, err := dialer.Dial(url, nil)
+	conn, _, err := dialer.Dial(url, opts.Header)
 	if err != nil {
 		return nil, &errs.ConnectionError{URL: url, Cause: err}
 	}
@@ -33,9 +66,40 @@ func Connect(url string) (*Connection, error) {
 	// Set read limit to handle large messages (e.g., screenshots from high-res displays)
 	conn.SetReadLimit(maxMessageSize)
 
-	return &Connection{
+	c := &Connection{
+		url:  url,
+		opts: opts,
 		conn: conn,
-	}, nil
+		done: make(chan struct{}),
+	}
+	if opts.KeepAlive > 0 {
+		c.startKeepAlive(opts.KeepAlive)
+	}
+	return c, nil
+}
+
+// startKeepAlive pings the browser every interval and extends the read
+// deadline whenever a frame arrives. The first deadline is set by Receive.
+func (c *Connection) startKeepAlive(interval time.Duration) {
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(2 * interval))
+	})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval)); err != nil {
+					return
+				}
+			case <-c.done:
+				return
+			}
+		}
+	}()
 }
 
 // Send sends a text message over the WebSocket.
@@ -53,14 +117,27 @@ func (c *Connection) Send(msg string) error {
 // Receive receives a text message from the WebSocket.
 // Blocks until a message is received.
 func (c *Connection) Receive() (string, error) {
-	if c.closed {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+
+	if closed {
 		return "", fmt.Errorf("connection closed")
 	}
 
+	if c.opts.KeepAlive > 0 {
+		c.armDeadline.Do(func() {
+			c.conn.SetReadDeadline(time.Now().Add(2 * c.opts.KeepAlive))
+		})
+	}
+
 	msgType, msg, err := c.conn.ReadMessage()
 	if err != nil {
 		return "", err
 	}
+	if c.opts.KeepAlive > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(2 * c.opts.KeepAlive))
+	}
 
 	if msgType != websocket.TextMessage {
 		return "", fmt.Errorf("expected text message, got type %d", msgType)
@@ -69,6 +146,13 @@ func (c *Connection) Receive() (string, error) {
 	return string(msg), nil
 }
 
+// isClosed reports whether Close has been called.
+func (c *Connection) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
 // Close closes the WebSocket connection.
 func (c *Connection) Close() error {
 	c.mu.Lock()
@@ -79,6 +163,7 @@ func (c *Connection) Close() error {
 	}
 
 	c.closed = true
+	close(c.done)
 
 	// Send close message
 	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
@@ -0,0 +1,83 @@
+package bidi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// newEchoServer starts a WebSocket server that echoes text messages back.
+func newEchoServer(t *testing.T) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			msgType, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(msgType, msg); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func TestKeepAliveIdleBeforeFirstReceive(t *testing.T) {
+	const interval = 20 * time.Millisecond
+
+	conn, err := ConnectWithOptions(newEchoServer(t), DialOptions{KeepAlive: interval})
+	if err != nil {
+		t.Fatalf("ConnectWithOptions: %v", err)
+	}
+	defer conn.Close()
+
+	// Stay idle for well over two intervals before reading anything.
+	time.Sleep(5 * interval)
+
+	if err := conn.Send("hello"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	msg, err := conn.Receive()
+	if err != nil {
+		t.Fatalf("Receive after idle gap: %v", err)
+	}
+	if msg != "hello" {
+		t.Errorf("Receive = %q, want %q", msg, "hello")
+	}
+}
+
+func TestKeepAlivePongsExtendDeadline(t *testing.T) {
+	const interval = 20 * time.Millisecond
+
+	conn, err := ConnectWithOptions(newEchoServer(t), DialOptions{KeepAlive: interval})
+	if err != nil {
+		t.Fatalf("ConnectWithOptions: %v", err)
+	}
+	defer conn.Close()
+
+	// The server answers pings while Receive blocks, so a reply sent after
+	// several intervals still arrives.
+	go func() {
+		time.Sleep(5 * interval)
+		conn.Send("late")
+	}()
+	msg, err := conn.Receive()
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if msg != "late" {
+		t.Errorf("Receive = %q, want %q", msg, "late")
+	}
+}
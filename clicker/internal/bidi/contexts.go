@@ -0,0 +1,208 @@
+package bidi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// contextTrackingEvents keep the tracked top-level contexts up to date.
+var contextTrackingEvents = []string{
+	"browsingContext.contextCreated",
+	"browsingContext.contextDestroyed",
+	"browsingContext.load",
+	"browsingContext.fragmentNavigated",
+}
+
+// TrackContexts starts tracking top-level browsing contexts from context and
+// navigation events, so ContextByURL and ContextByIndex can resolve tabs
+// without re-reading the tree. It is called automatically by those methods
+// and does nothing if tracking has already started. A call made while
+// another is still seeding waits for it.
+func (c *Client) TrackContexts() error {
+	c.contextsMu.Lock()
+	for c.trackReady != nil {
+		if c.tracking {
+			c.contextsMu.Unlock()
+			return nil
+		}
+		// Another call is seeding; if it fails, try again here
+		ready := c.trackReady
+		c.contextsMu.Unlock()
+		<-ready
+		c.contextsMu.Lock()
+	}
+	ready := make(chan struct{})
+	c.trackReady = ready
+	c.contextsMu.Unlock()
+
+	// Contexts reported by events before the tree is read are newer than
+	// the tree, so the seed skips them; nil once seeding is done
+	seen := make(map[string]bool)
+	markSeen := func(context string) {
+		c.contextsMu.Lock()
+		if seen != nil {
+			seen[context] = true
+		}
+		c.contextsMu.Unlock()
+	}
+
+	handlerIDs := make(map[string]int64, len(contextTrackingEvents))
+	handlerIDs["browsingContext.contextCreated"] = c.addHandler("browsingContext.contextCreated", func(params json.RawMessage) {
+		var info ContextInfo
+		if err := json.Unmarshal(params, &info); err == nil && info.Parent == "" {
+			markSeen(info.Context)
+			c.trackContext(info.Context, info.URL)
+		}
+	})
+	handlerIDs["browsingContext.contextDestroyed"] = c.addHandler("browsingContext.contextDestroyed", func(params json.RawMessage) {
+		var info ContextInfo
+		if err := json.Unmarshal(params, &info); err == nil {
+			markSeen(info.Context)
+			c.untrackContext(info.Context)
+		}
+	})
+	updateURL := func(params json.RawMessage) {
+		var event NavigationEvent
+		if err := json.Unmarshal(params, &event); err == nil {
+			c.updateContextURL(event.Context, event.URL)
+		}
+	}
+	handlerIDs["browsingContext.load"] = c.addHandler("browsingContext.load", updateURL)
+	handlerIDs["browsingContext.fragmentNavigated"] = c.addHandler("browsingContext.fragmentNavigated", updateURL)
+
+	fail := func(err error) error {
+		for method, id := range handlerIDs {
+			c.removeHandler(method, id)
+		}
+		c.contextsMu.Lock()
+		c.trackReady = nil
+		c.contexts = nil
+		c.contextsMu.Unlock()
+		close(ready)
+		return err
+	}
+
+	subscriptionID, err := c.subscribe(contextTrackingEvents, nil)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Seed from the tree after subscribing so no context is missed
+	tree, err := c.GetTreeDepth(0)
+	if err != nil {
+		// Undo only this call's subscription, not ones the caller holds
+		c.releaseSubscription(subscriptionID, contextTrackingEvents, nil)
+		return fail(fmt.Errorf("failed to get browsing context: %w", err))
+	}
+
+	// Contexts in the tree were opened before any the events reported
+	var seeded []ContextInfo
+	c.contextsMu.Lock()
+	for _, info := range tree.Contexts {
+		if !seen[info.Context] {
+			seeded = append(seeded, ContextInfo{Context: info.Context, URL: info.URL})
+		}
+	}
+	c.contexts = append(seeded, c.contexts...)
+	seen = nil
+	c.tracking = true
+	c.contextsMu.Unlock()
+	close(ready)
+	return nil
+}
+
+// trackContext adds a top-level context, or updates its URL if already known.
+func (c *Client) trackContext(context, url string) {
+	c.contextsMu.Lock()
+	defer c.contextsMu.Unlock()
+
+	for i := range c.contexts {
+		if c.contexts[i].Context == context {
+			c.contexts[i].URL = url
+			return
+		}
+	}
+	c.contexts = append(c.contexts, ContextInfo{Context: context, URL: url})
+}
+
+// untrackContext removes a destroyed context.
+func (c *Client) untrackContext(context string) {
+	c.contextsMu.Lock()
+	defer c.contextsMu.Unlock()
+
+	for i := range c.contexts {
+		if c.contexts[i].Context == context {
+			c.contexts = append(c.contexts[:i], c.contexts[i+1:]...)
+			return
+		}
+	}
+}
+
+// updateContextURL records a navigation of a tracked context.
+func (c *Client) updateContextURL(context, url string) {
+	c.contextsMu.Lock()
+	defer c.contextsMu.Unlock()
+
+	for i := range c.contexts {
+		if c.contexts[i].Context == context {
+			c.contexts[i].URL = url
+			return
+		}
+	}
+}
+
+// Contexts returns the tracked top-level contexts in the order they were
+// opened.
+func (c *Client) Contexts() ([]ContextInfo, error) {
+	if err := c.TrackContexts(); err != nil {
+		return nil, err
+	}
+
+	c.contextsMu.Lock()
+	defer c.contextsMu.Unlock()
+	return append([]ContextInfo(nil), c.contexts...), nil
+}
+
+// ContextByIndex returns the id of the i-th top-level context, counting in
+// the order the contexts were opened.
+func (c *Client) ContextByIndex(i int) (string, error) {
+	contexts, err := c.Contexts()
+	if err != nil {
+		return "", err
+	}
+	if i < 0 || i >= len(contexts) {
+		return "", fmt.Errorf("no browsing context at index %d (%d open)", i, len(contexts))
+	}
+	return contexts[i].Context, nil
+}
+
+// ContextByURL returns the id of the first top-level context whose URL
+// matches pattern, where "*" matches any run of characters.
+func (c *Client) ContextByURL(pattern string) (string, error) {
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return "", err
+	}
+
+	contexts, err := c.Contexts()
+	if err != nil {
+		return "", err
+	}
+	for _, info := range contexts {
+		if re.MatchString(info.URL) {
+			return info.Context, nil
+		}
+	}
+	return "", fmt.Errorf("no browsing context with URL matching %s", pattern)
+}
+
+// globRegexp compiles a pattern where "*" matches any run of characters.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+}
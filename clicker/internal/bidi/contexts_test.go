@@ -0,0 +1,80 @@
+package bidi
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestContextsWaitsForSeed(t *testing.T) {
+	transport := newFakeTransport()
+	defer transport.Close()
+	client := NewClient(transport)
+
+	serveFake(transport, func(cmd fakeCommand) string {
+		if cmd.Method == "browsingContext.getTree" {
+			time.Sleep(20 * time.Millisecond)
+			return fmt.Sprintf(`{"id":%d,"type":"success","result":{"contexts":[{"context":"tab-1","url":"about:blank","children":[]}]}}`, cmd.ID)
+		}
+		return success(cmd)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			contexts, err := client.Contexts()
+			if err != nil {
+				t.Errorf("Contexts: %v", err)
+				return
+			}
+			if len(contexts) != 1 || contexts[0].Context != "tab-1" {
+				t.Errorf("Contexts = %v, want [tab-1]", contexts)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTrackContextsFailureKeepsCallerSubscription(t *testing.T) {
+	transport := newFakeTransport()
+	defer transport.Close()
+	client := NewClient(transport)
+
+	var mu sync.Mutex
+	var unsubscribed []string
+	serveFake(transport, func(cmd fakeCommand) string {
+		switch cmd.Method {
+		case "browsingContext.getTree":
+			return fmt.Sprintf(`{"id":%d,"type":"error","error":"unknown error","message":"tree unavailable"}`, cmd.ID)
+		case "session.unsubscribe":
+			mu.Lock()
+			unsubscribed = append(unsubscribed, string(cmd.Params))
+			mu.Unlock()
+		}
+		return success(cmd)
+	})
+
+	if err := client.OnContextCreated(func(ContextInfo) {}); err != nil {
+		t.Fatalf("OnContextCreated: %v", err)
+	}
+	if err := client.TrackContexts(); err == nil {
+		t.Fatal("TrackContexts succeeded, want getTree error")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, params := range unsubscribed {
+		if strings.Contains(params, "browsingContext.contextCreated") {
+			t.Errorf("failed TrackContexts unsubscribed the caller's event: %s", params)
+		}
+	}
+	client.subscriptionsMu.Lock()
+	defer client.subscriptionsMu.Unlock()
+	if len(client.subscriptions) != 1 || client.subscriptions[0].Events[0] != "browsingContext.contextCreated" {
+		t.Errorf("subscriptions = %v, want only the caller's", client.subscriptions)
+	}
+}
@@ -0,0 +1,137 @@
+package bidi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CookieJar reads the browser cookies matching filter and returns them in a
+// new http.CookieJar, so an http.Client can continue a browser session. Each
+// cookie is stored for its own domain and path, with https used for secure
+// cookies; domain cookies (leading ".") also match subdomains.
+func (c *Client) CookieJar(filter CookieFilter) (http.CookieJar, error) {
+	cookies, err := c.GetCookies(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
+	}
+
+	for _, cookie := range cookies {
+		httpCookie, u, err := toHTTPCookie(cookie)
+		if err != nil {
+			return nil, err
+		}
+		jar.SetCookies(u, []*http.Cookie{httpCookie})
+	}
+
+	return jar, nil
+}
+
+// toHTTPCookie converts a browser cookie to an http.Cookie and the URL it
+// should be stored under.
+func toHTTPCookie(cookie Cookie) (*http.Cookie, *url.URL, error) {
+	value, err := cookie.Value.Bytes()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode cookie %s: %w", cookie.Name, err)
+	}
+
+	host := strings.TrimPrefix(cookie.Domain, ".")
+	scheme := "http"
+	if cookie.Secure {
+		scheme = "https"
+	}
+	path := cookie.Path
+	if path == "" {
+		path = "/"
+	}
+
+	httpCookie := &http.Cookie{
+		Name:     cookie.Name,
+		Value:    string(value),
+		Path:     path,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HTTPOnly,
+	}
+	// A leading dot marks a domain cookie; without it the cookie is host-only
+	if strings.HasPrefix(cookie.Domain, ".") {
+		httpCookie.Domain = host
+	}
+	if cookie.Expiry != nil {
+		httpCookie.Expires = time.Unix(*cookie.Expiry, 0)
+	}
+	switch cookie.SameSite {
+	case "strict":
+		httpCookie.SameSite = http.SameSiteStrictMode
+	case "lax":
+		httpCookie.SameSite = http.SameSiteLaxMode
+	case "none":
+		httpCookie.SameSite = http.SameSiteNoneMode
+	}
+
+	return httpCookie, &url.URL{Scheme: scheme, Host: host, Path: path}, nil
+}
+
+// LoadCookieJar copies the cookies jar would send to rawURL into the
+// browser's default partition. The http.CookieJar interface only exposes
+// cookie names and values, so cookies are set host-only for the URL's host
+// with path "/" and no expiry, unless the jar reports those fields itself.
+// Cookies without a name are skipped.
+func (c *Client) LoadCookieJar(jar http.CookieJar, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("cookie URL must be http or https: %s", rawURL)
+	}
+
+	for _, httpCookie := range jar.Cookies(u) {
+		if httpCookie.Name == "" {
+			continue
+		}
+
+		if err := c.SetCookie(fromHTTPCookie(httpCookie, u), nil); err != nil {
+			return fmt.Errorf("failed to set cookie %s: %w", httpCookie.Name, err)
+		}
+	}
+	return nil
+}
+
+// fromHTTPCookie converts an http.Cookie returned for u to a browser cookie.
+func fromHTTPCookie(httpCookie *http.Cookie, u *url.URL) Cookie {
+	cookie := Cookie{
+		Name:     httpCookie.Name,
+		Value:    StringValue(httpCookie.Value),
+		Domain:   u.Hostname(),
+		Path:     "/",
+		HTTPOnly: httpCookie.HttpOnly,
+		Secure:   httpCookie.Secure,
+	}
+	if httpCookie.Domain != "" {
+		cookie.Domain = "." + strings.TrimPrefix(httpCookie.Domain, ".")
+	}
+	if httpCookie.Path != "" {
+		cookie.Path = httpCookie.Path
+	}
+	if !httpCookie.Expires.IsZero() {
+		expiry := httpCookie.Expires.Unix()
+		cookie.Expiry = &expiry
+	}
+	switch httpCookie.SameSite {
+	case http.SameSiteStrictMode:
+		cookie.SameSite = "strict"
+	case http.SameSiteLaxMode:
+		cookie.SameSite = "lax"
+	case http.SameSiteNoneMode:
+		cookie.SameSite = "none"
+	}
+	return cookie
+}
@@ -0,0 +1,63 @@
+package bidi
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// HandledDialog records a dialog answered by AutoHandleDialogs.
+type HandledDialog struct {
+	UserPromptEvent
+	Err error // error from browsingContext.handleUserPrompt, if any
+}
+
+// DialogHandler answers dialogs automatically until stopped.
+type DialogHandler struct {
+	client    *Client
+	handlerID int64
+
+	mu      sync.Mutex
+	handled []HandledDialog
+}
+
+// AutoHandleDialogs answers every dialog that opens: accepting or dismissing
+// it and, for prompts, entering text before accepting. Call Stop on the
+// returned handler to disable it.
+func (c *Client) AutoHandleDialogs(accept bool, text string) (*DialogHandler, error) {
+	h := &DialogHandler{client: c}
+	h.handlerID = c.addHandler("browsingContext.userPromptOpened", func(params json.RawMessage) {
+		var event UserPromptEvent
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+
+		// Text only applies to prompts
+		userText := ""
+		if event.Type == "prompt" {
+			userText = text
+		}
+		err := c.HandleUserPrompt(event.Context, accept, userText)
+
+		h.mu.Lock()
+		h.handled = append(h.handled, HandledDialog{UserPromptEvent: event, Err: err})
+		h.mu.Unlock()
+	})
+
+	if err := c.Subscribe([]string{"browsingContext.userPromptOpened"}, nil); err != nil {
+		c.removeHandler("browsingContext.userPromptOpened", h.handlerID)
+		return nil, err
+	}
+	return h, nil
+}
+
+// Handled returns the dialogs answered so far.
+func (h *DialogHandler) Handled() []HandledDialog {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]HandledDialog(nil), h.handled...)
+}
+
+// Stop stops answering dialogs. Recorded dialogs remain available.
+func (h *DialogHandler) Stop() {
+	h.client.removeHandler("browsingContext.userPromptOpened", h.handlerID)
+}
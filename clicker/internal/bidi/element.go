@@ -1,6 +1,7 @@
 package bidi
 
 import (
+	stdcontext "context"
 	"encoding/json"
 	"fmt"
 
@@ -73,17 +74,9 @@ func (c *Client) FindElement(context, selector string) (*ElementInfo, error) {
 		return nil, err
 	}
 
-	// Parse the result
-	var callResult struct {
-		Type   string          `json:"type"`
-		Result json.RawMessage `json:"result"`
-	}
-	if err := json.Unmarshal(msg.Result, &callResult); err != nil {
-		return nil, fmt.Errorf("failed to parse script.callFunction result: %w", err)
-	}
-
-	if callResult.Type == "exception" {
-		return nil, fmt.Errorf("script exception: %s", string(callResult.Result))
+	callResult, err := parseEvaluateResult("script.callFunction", msg.Result)
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse the remote value (string containing JSON)
@@ -114,3 +107,358 @@ func (c *Client) FindElement(context, selector string) (*ElementInfo, error) {
 func (info *ElementInfo) GetCenter() (float64, float64) {
 	return info.Box.X + info.Box.Width/2, info.Box.Y + info.Box.Height/2
 }
+
+// Locator selects nodes for LocateNodes.
+type Locator struct {
+	// Type is "css", "xpath", "innerText" or "accessibility".
+	Type string
+
+	// Value is the CSS selector, XPath expression or text to match.
+	// Unused for accessibility locators.
+	Value string
+
+	// Role and Name match the computed accessibility role and name.
+	// Only used for accessibility locators; at least one must be set.
+	Role string
+	Name string
+
+	// MatchType is "full" (the browser's default) or "partial", and
+	// IgnoreCase compares text case-insensitively. MaxDepth limits how many
+	// levels below each start node are searched. Only used for innerText
+	// locators; unset fields are not sent.
+	MatchType  string
+	IgnoreCase bool
+	MaxDepth   *int
+}
+
+// CSSLocator returns a locator that matches a CSS selector.
+func CSSLocator(selector string) Locator {
+	return Locator{Type: "css", Value: selector}
+}
+
+// XPathLocator returns a locator that matches an XPath expression.
+func XPathLocator(expression string) Locator {
+	return Locator{Type: "xpath", Value: expression}
+}
+
+// InnerTextLocator returns a locator that matches elements by their text.
+func InnerTextLocator(text string) Locator {
+	return Locator{Type: "innerText", Value: text}
+}
+
+// AccessibilityLocator returns a locator that matches elements by their
+// computed accessibility role and name. Either may be empty to match any.
+func AccessibilityLocator(role, name string) Locator {
+	return Locator{Type: "accessibility", Role: role, Name: name}
+}
+
+// PartialTextLocator returns a locator that matches elements whose text
+// contains text.
+func PartialTextLocator(text string) Locator {
+	return Locator{Type: "innerText", Value: text, MatchType: "partial"}
+}
+
+// serialize returns the BiDi locator form.
+func (l Locator) serialize() (map[string]interface{}, error) {
+	switch l.Type {
+	case "css", "xpath":
+		if l.Value == "" {
+			return nil, fmt.Errorf("%s locator requires a value", l.Type)
+		}
+		return map[string]interface{}{"type": l.Type, "value": l.Value}, nil
+	case "innerText":
+		if l.Value == "" {
+			return nil, fmt.Errorf("innerText locator requires a value")
+		}
+		locator := map[string]interface{}{"type": "innerText", "value": l.Value}
+		switch l.MatchType {
+		case "":
+		case "full", "partial":
+			locator["matchType"] = l.MatchType
+		default:
+			return nil, fmt.Errorf("invalid innerText match type: %s", l.MatchType)
+		}
+		if l.IgnoreCase {
+			locator["ignoreCase"] = true
+		}
+		if l.MaxDepth != nil {
+			if *l.MaxDepth < 0 {
+				return nil, fmt.Errorf("max depth must not be negative, got %d", *l.MaxDepth)
+			}
+			locator["maxDepth"] = *l.MaxDepth
+		}
+		return locator, nil
+	case "accessibility":
+		value := map[string]interface{}{}
+		if l.Role != "" {
+			value["role"] = l.Role
+		}
+		if l.Name != "" {
+			value["name"] = l.Name
+		}
+		if len(value) == 0 {
+			return nil, fmt.Errorf("accessibility locator requires a role or name")
+		}
+		return map[string]interface{}{"type": "accessibility", "value": value}, nil
+	default:
+		return nil, fmt.Errorf("invalid locator type: %s", l.Type)
+	}
+}
+
+// LocateNodesResult represents the result of browsingContext.locateNodes.
+type LocateNodesResult struct {
+	Nodes []RemoteValue `json:"nodes"`
+}
+
+// LocateNodes finds nodes matching a locator. The returned nodes carry a
+// sharedId, so they can be passed to CallFunction as arguments.
+// If context is empty, it uses the first available context.
+func (c *Client) LocateNodes(context string, locator Locator) ([]RemoteValue, error) {
+	return c.LocateNodesWithOpts(context, locator, LocateNodesOpts{})
+}
+
+// LocateByRole finds elements by accessibility role and name, such as
+// role "button" and name "Sign in". Either may be empty to match any, but
+// not both. Nodes are returned as by LocateNodes.
+func (c *Client) LocateByRole(context, role, name string) ([]RemoteValue, error) {
+	return c.LocateNodes(context, AccessibilityLocator(role, name))
+}
+
+// LocateNodesOpts configures LocateNodesWithOpts.
+type LocateNodesOpts struct {
+	// MaxNodeCount caps the number of nodes returned. 0 means no limit.
+	MaxNodeCount int
+
+	// StartNodes scopes the search to descendants of these nodes,
+	// which must carry a sharedId.
+	StartNodes []*RemoteValue
+}
+
+// LocateNodesWithOpts finds nodes matching a locator, optionally limited in
+// number and scoped to previously located nodes.
+// If context is empty, it uses the first available context.
+func (c *Client) LocateNodesWithOpts(context string, locator Locator, opts LocateNodesOpts) ([]RemoteValue, error) {
+	return c.LocateNodesContext(stdcontext.Background(), context, locator, opts)
+}
+
+// LocateNodesContext is LocateNodesWithOpts with a context that bounds how
+// long it waits for the browser.
+// If context is empty, it uses the first available context.
+func (c *Client) LocateNodesContext(ctx stdcontext.Context, context string, locator Locator, opts LocateNodesOpts) ([]RemoteValue, error) {
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return nil, fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	loc, err := locator.serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]interface{}{
+		"context": context,
+		"locator": loc,
+	}
+	if opts.MaxNodeCount < 0 {
+		return nil, fmt.Errorf("max node count must not be negative, got %d", opts.MaxNodeCount)
+	}
+	if opts.MaxNodeCount > 0 {
+		params["maxNodeCount"] = opts.MaxNodeCount
+	}
+	if len(opts.StartNodes) > 0 {
+		startNodes := make([]map[string]interface{}, len(opts.StartNodes))
+		for i, node := range opts.StartNodes {
+			if node == nil || node.SharedID == "" {
+				return nil, fmt.Errorf("start node %d has no sharedId", i)
+			}
+			startNodes[i] = map[string]interface{}{"sharedId": node.SharedID}
+		}
+		params["startNodes"] = startNodes
+	}
+
+	msg, err := c.SendCommandContext(ctx, "browsingContext.locateNodes", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result LocateNodesResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse browsingContext.locateNodes result: %w", err)
+	}
+
+	return result.Nodes, nil
+}
+
+// checkNode returns an error if node cannot be passed to a script.
+func checkNode(node *RemoteValue) error {
+	if node.Reference() == nil {
+		return fmt.Errorf("node has no sharedId or handle")
+	}
+	return nil
+}
+
+// GetAttribute returns the value of a node's attribute and whether the
+// attribute is present, so an empty value can be told apart from a missing
+// attribute.
+// If context is empty, it uses the first available context.
+func (c *Client) GetAttribute(context string, node *RemoteValue, name string) (string, bool, error) {
+	if err := checkNode(node); err != nil {
+		return "", false, err
+	}
+
+	script := `(el, name) => el.hasAttribute(name) ? el.getAttribute(name) : null`
+
+	result, err := c.CallFunction(context, script, []interface{}{node, name})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get attribute %s: %w", name, err)
+	}
+
+	value, ok := result.(string)
+	return value, ok, nil
+}
+
+// GetProperty returns the decoded value of a node's DOM property, such as
+// "value" or "checked".
+// If context is empty, it uses the first available context.
+func (c *Client) GetProperty(context string, node *RemoteValue, name string) (interface{}, error) {
+	if err := checkNode(node); err != nil {
+		return nil, err
+	}
+
+	result, err := c.CallFunction(context, `(el, name) => el[name]`, []interface{}{node, name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property %s: %w", name, err)
+	}
+	return result, nil
+}
+
+// GetText returns a node's rendered text (innerText), falling back to
+// textContent for nodes that are not rendered elements.
+// If context is empty, it uses the first available context.
+func (c *Client) GetText(context string, node *RemoteValue) (string, error) {
+	return c.readString(context, node, `(el) => el.innerText ?? el.textContent ?? ''`, "text")
+}
+
+// GetInnerHTML returns the HTML markup inside a node.
+// If context is empty, it uses the first available context.
+func (c *Client) GetInnerHTML(context string, node *RemoteValue) (string, error) {
+	return c.readString(context, node, `(el) => el.innerHTML ?? ''`, "inner HTML")
+}
+
+// readString calls a function with the node as its argument and returns the
+// string it produces.
+func (c *Client) readString(context string, node *RemoteValue, script, what string) (string, error) {
+	if err := checkNode(node); err != nil {
+		return "", err
+	}
+
+	result, err := c.CallFunction(context, script, []interface{}{node})
+	if err != nil {
+		return "", fmt.Errorf("failed to get %s: %w", what, err)
+	}
+
+	s, ok := result.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected %s result: %v", what, result)
+	}
+	return s, nil
+}
+
+// Rect is an element's bounding rectangle in CSS pixels.
+type Rect struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// Center returns the center point of the rectangle.
+func (r Rect) Center() (float64, float64) {
+	return r.X + r.Width/2, r.Y + r.Height/2
+}
+
+// GetBoundingRect returns a node's bounding rectangle from
+// getBoundingClientRect. Coordinates are relative to the viewport of the
+// node's own document, so for a node inside an iframe they must be offset
+// by the iframe's position to get top-level coordinates.
+// If context is empty, it uses the first available context.
+func (c *Client) GetBoundingRect(context string, node *RemoteValue) (Rect, error) {
+	if err := checkNode(node); err != nil {
+		return Rect{}, err
+	}
+
+	script := `
+		(el) => {
+			const rect = el.getBoundingClientRect();
+			return { x: rect.x, y: rect.y, width: rect.width, height: rect.height };
+		}
+	`
+
+	result, err := c.CallFunction(context, script, []interface{}{node})
+	if err != nil {
+		return Rect{}, fmt.Errorf("failed to get bounding rect: %w", err)
+	}
+
+	return rectFromResult(result)
+}
+
+// rectFromResult converts a decoded {x, y, width, height} object to a Rect.
+func rectFromResult(result interface{}) (Rect, error) {
+	obj, ok := result.(map[string]interface{})
+	if !ok {
+		return Rect{}, fmt.Errorf("unexpected bounding rect result: %v", result)
+	}
+
+	var rect Rect
+	rect.X, _ = obj["x"].(float64)
+	rect.Y, _ = obj["y"].(float64)
+	rect.Width, _ = obj["width"].(float64)
+	rect.Height, _ = obj["height"].(float64)
+	return rect, nil
+}
+
+// isVisibleScript reports whether an element is rendered, not transparent
+// and has a non-empty box.
+const isVisibleScript = `
+	(el) => {
+		const style = window.getComputedStyle(el);
+		const rect = el.getBoundingClientRect();
+		return style.visibility !== 'hidden' && style.display !== 'none' &&
+			parseFloat(style.opacity) > 0 && rect.width > 0 && rect.height > 0;
+	}
+`
+
+// IsVisible reports whether a node is displayed, not hidden or fully
+// transparent, and has a non-zero size.
+// If context is empty, it uses the first available context.
+func (c *Client) IsVisible(context string, node *RemoteValue) (bool, error) {
+	return c.checkElement(context, node, isVisibleScript, "visibility")
+}
+
+// IsEnabled reports whether a node is not disabled.
+// If context is empty, it uses the first available context.
+func (c *Client) IsEnabled(context string, node *RemoteValue) (bool, error) {
+	return c.checkElement(context, node, `(el) => !el.disabled`, "enabled state")
+}
+
+// checkElement calls a predicate with the node as its argument.
+func (c *Client) checkElement(context string, node *RemoteValue, script, what string) (bool, error) {
+	if err := checkNode(node); err != nil {
+		return false, err
+	}
+
+	result, err := c.CallFunction(context, script, []interface{}{node})
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", what, err)
+	}
+
+	ok, _ := result.(bool)
+	return ok, nil
+}
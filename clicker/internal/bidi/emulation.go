@@ -0,0 +1,115 @@
+package bidi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GeolocationCoordinates is an emulated position. Latitude and longitude are
+// in degrees; accuracy and altitude in meters; heading in degrees clockwise
+// from north; speed in meters per second.
+type GeolocationCoordinates struct {
+	Latitude         float64  `json:"latitude"`
+	Longitude        float64  `json:"longitude"`
+	Accuracy         *float64 `json:"accuracy,omitempty"` // defaults to 1
+	Altitude         *float64 `json:"altitude,omitempty"`
+	AltitudeAccuracy *float64 `json:"altitudeAccuracy,omitempty"`
+	Heading          *float64 `json:"heading,omitempty"`
+	Speed            *float64 `json:"speed,omitempty"`
+}
+
+// SetGeolocationOverride sets the position reported to pages. If coords is
+// nil, pages get a "position unavailable" error instead. If contexts is
+// empty, the override applies to all contexts.
+func (c *Client) SetGeolocationOverride(contexts []string, coords *GeolocationCoordinates) error {
+	params := map[string]interface{}{}
+	if coords != nil {
+		params["coordinates"] = coords
+	} else {
+		params["error"] = map[string]interface{}{"type": "positionUnavailable"}
+	}
+
+	return c.setOverride("emulation.setGeolocationOverride", contexts, params)
+}
+
+// ClearGeolocationOverride removes a geolocation override.
+func (c *Client) ClearGeolocationOverride(contexts []string) error {
+	params := map[string]interface{}{
+		"coordinates": nil,
+	}
+
+	return c.setOverride("emulation.setGeolocationOverride", contexts, params)
+}
+
+// setOverride sends an emulation command, scoped to contexts if any are given.
+func (c *Client) setOverride(method string, contexts []string, params map[string]interface{}) error {
+	if len(contexts) > 0 {
+		params["contexts"] = contexts
+	}
+
+	_, err := c.SendCommand(method, params)
+	return err
+}
+
+// SetTimezoneOverride sets the timezone seen by pages to an IANA id such as
+// "America/New_York" or an offset such as "+05:00". An empty timezone clears
+// the override. Unknown ids are rejected by the browser.
+func (c *Client) SetTimezoneOverride(contexts []string, timezone string) error {
+	params := map[string]interface{}{
+		"timezone": nil,
+	}
+	if timezone != "" {
+		if strings.ContainsAny(timezone, " \t\r\n") {
+			return fmt.Errorf("invalid timezone: %q", timezone)
+		}
+		params["timezone"] = timezone
+	}
+
+	return c.setOverride("emulation.setTimezoneOverride", contexts, params)
+}
+
+// SetLocaleOverride sets the locale seen by pages, such as "fr-FR", which
+// affects navigator.language and Intl formatting. An empty locale clears
+// the override.
+func (c *Client) SetLocaleOverride(contexts []string, locale string) error {
+	params := map[string]interface{}{
+		"locale": nil,
+	}
+	if locale != "" {
+		params["locale"] = locale
+	}
+
+	return c.setOverride("emulation.setLocaleOverride", contexts, params)
+}
+
+// ScreenOrientation is an emulated screen orientation. Natural is the
+// device's natural orientation, "portrait" or "landscape"; Type is one of
+// "portrait-primary", "portrait-secondary", "landscape-primary" or
+// "landscape-secondary".
+type ScreenOrientation struct {
+	Natural string `json:"natural"`
+	Type    string `json:"type"`
+}
+
+// SetScreenOrientationOverride sets the screen orientation seen by pages.
+// A nil orientation clears the override.
+func (c *Client) SetScreenOrientationOverride(contexts []string, orientation *ScreenOrientation) error {
+	params := map[string]interface{}{
+		"screenOrientation": nil,
+	}
+	if orientation != nil {
+		switch orientation.Natural {
+		case "portrait", "landscape":
+		default:
+			return fmt.Errorf("invalid natural orientation: %s", orientation.Natural)
+		}
+		switch orientation.Type {
+		case "portrait-primary", "portrait-secondary", "landscape-primary", "landscape-secondary":
+		default:
+			return fmt.Errorf("invalid orientation type: %s", orientation.Type)
+		}
+		params["screenOrientation"] = orientation
+	}
+
+	return c.setOverride("emulation.setScreenOrientationOverride", contexts, params)
+}
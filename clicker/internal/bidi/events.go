@@ -0,0 +1,141 @@
+package bidi
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// On registers a handler for a BiDi event method such as "log.entryAdded".
+// Handlers run on a dedicated dispatch goroutine in the order events arrive,
+// so they may safely send commands. Events are only delivered once they
+// have been enabled with Subscribe.
+func (c *Client) On(method string, handler func(json.RawMessage)) {
+	c.addHandler(method, handler)
+}
+
+// eventHandler is a registered event handler with an id for removal.
+type eventHandler struct {
+	id int64
+	fn func(json.RawMessage)
+}
+
+// addHandler registers a handler and returns an id for removeHandler.
+func (c *Client) addHandler(method string, handler func(json.RawMessage)) int64 {
+	c.handlersMu.Lock()
+	c.nextHandlerID++
+	id := c.nextHandlerID
+	c.handlers[method] = append(c.handlers[method], eventHandler{id: id, fn: handler})
+	c.handlersMu.Unlock()
+
+	c.startReadLoop()
+	return id
+}
+
+// removeHandler unregisters a handler added with addHandler.
+func (c *Client) removeHandler(method string, id int64) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+
+	// Build a new slice so a dispatch in progress keeps its own copy
+	handlers := c.handlers[method]
+	kept := make([]eventHandler, 0, len(handlers))
+	for _, h := range handlers {
+		if h.id != id {
+			kept = append(kept, h)
+		}
+	}
+	if len(kept) == 0 {
+		delete(c.handlers, method)
+		return
+	}
+	c.handlers[method] = kept
+}
+
+// subscribeHandler registers a handler for method and subscribes to it in
+// the given contexts, or globally if contexts is empty. If the subscription
+// fails, the handler is removed again.
+func (c *Client) subscribeHandler(method string, contexts []string, handler func(json.RawMessage)) error {
+	id := c.addHandler(method, handler)
+	if err := c.Subscribe([]string{method}, contexts); err != nil {
+		c.removeHandler(method, id)
+		return err
+	}
+	return nil
+}
+
+// dispatchLoop passes queued events to their handlers until the queue closes.
+func (c *Client) dispatchLoop() {
+	for {
+		msg, ok := c.events.pop()
+		if !ok {
+			return
+		}
+		c.dispatchEvent(msg)
+	}
+}
+
+// dispatchEvent calls the handlers registered for an event.
+func (c *Client) dispatchEvent(msg *Message) {
+	c.handlersMu.RLock()
+	handlers := c.handlers[msg.Method]
+	c.handlersMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler.fn(msg.Params)
+	}
+}
+
+// eventQueue is an unbounded FIFO of events, so the read loop never blocks
+// on a slow handler.
+type eventQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	items  []*Message
+	closed bool
+}
+
+func newEventQueue() *eventQueue {
+	q := &eventQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push appends an event to the queue.
+func (q *eventQueue) push(msg *Message) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.items = append(q.items, msg)
+	q.cond.Signal()
+}
+
+// pop blocks until an event is available. It returns false once the queue is
+// closed and drained.
+func (q *eventQueue) pop() (*Message, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		return nil, false
+	}
+
+	msg := q.items[0]
+	q.items[0] = nil
+	q.items = q.items[1:]
+	return msg, true
+}
+
+// close stops the queue and wakes the dispatcher.
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
@@ -0,0 +1,28 @@
+package bidi
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSubscribeHandlerRemovesHandlerOnError(t *testing.T) {
+	transport := newFakeTransport()
+	defer transport.Close()
+	client := NewClient(transport)
+
+	// Reject every command
+	serveFake(transport, func(cmd fakeCommand) string {
+		return fmt.Sprintf(`{"id":%d,"type":"error","error":"invalid argument","message":"unknown event"}`, cmd.ID)
+	})
+
+	err := client.OnContextCreated(func(ContextInfo) {})
+	if err == nil {
+		t.Fatal("OnContextCreated succeeded, want subscription error")
+	}
+
+	client.handlersMu.Lock()
+	defer client.handlersMu.Unlock()
+	if handlers := client.handlers["browsingContext.contextCreated"]; len(handlers) != 0 {
+		t.Errorf("%d handlers left after failed subscribe, want 0", len(handlers))
+	}
+}
@@ -1,11 +1,152 @@
 package bidi
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	errs "github.com/vibium/clicker/internal/errors"
 )
 
+// SourceActions is a sequence of actions for one input source.
+type SourceActions struct {
+	Type       string             `json:"type"` // "none", "key", "pointer" or "wheel"
+	ID         string             `json:"id"`
+	Parameters *PointerParameters `json:"parameters,omitempty"`
+	Actions    []Action           `json:"actions"`
+}
+
+// PointerParameters configures a pointer input source.
+type PointerParameters struct {
+	PointerType string `json:"pointerType,omitempty"` // "mouse", "pen" or "touch"
+}
+
+// Action is a single input action. Only the fields relevant to Type are sent.
+type Action struct {
+	// Type is "pause", "keyDown", "keyUp", "pointerDown", "pointerUp",
+	// "pointerMove" or "scroll".
+	Type string
+
+	Duration int    // milliseconds, for pause, pointerMove and scroll
+	Value    string // key value, for keyDown and keyUp
+	Button   int    // for pointerDown and pointerUp
+	X        int    // for pointerMove and scroll
+	Y        int    // for pointerMove and scroll
+	DeltaX   int    // for scroll
+	DeltaY   int    // for scroll
+
+	// Origin is "viewport" (default) or "pointer" for pointerMove and scroll.
+	Origin string
+
+	// Element makes X and Y relative to the center of a node instead of Origin.
+	Element *RemoteValue
+}
+
+// MarshalJSON encodes the action with the fields its type allows.
+func (a Action) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{"type": a.Type}
+
+	switch a.Type {
+	case "pause":
+		if a.Duration > 0 {
+			m["duration"] = a.Duration
+		}
+	case "keyDown", "keyUp":
+		m["value"] = a.Value
+	case "pointerDown", "pointerUp":
+		m["button"] = a.Button
+	case "pointerMove", "scroll":
+		m["x"] = a.X
+		m["y"] = a.Y
+		m["duration"] = a.Duration
+		if a.Type == "scroll" {
+			m["deltaX"] = a.DeltaX
+			m["deltaY"] = a.DeltaY
+		}
+		if a.Element != nil {
+			m["origin"] = map[string]interface{}{
+				"type":    "element",
+				"element": map[string]interface{}{"sharedId": a.Element.SharedID},
+			}
+		} else if a.Origin != "" {
+			m["origin"] = a.Origin
+		}
+	default:
+		return nil, fmt.Errorf("invalid action type: %s", a.Type)
+	}
+
+	return json.Marshal(m)
+}
+
+// PauseAction returns a pause of the given duration in milliseconds.
+func PauseAction(duration int) Action {
+	return Action{Type: "pause", Duration: duration}
+}
+
+// KeyDownAction returns a key press.
+func KeyDownAction(key string) Action {
+	return Action{Type: "keyDown", Value: key}
+}
+
+// KeyUpAction returns a key release.
+func KeyUpAction(key string) Action {
+	return Action{Type: "keyUp", Value: key}
+}
+
+// PointerDownAction returns a pointer button press.
+func PointerDownAction(button int) Action {
+	return Action{Type: "pointerDown", Button: button}
+}
+
+// PointerUpAction returns a pointer button release.
+func PointerUpAction(button int) Action {
+	return Action{Type: "pointerUp", Button: button}
+}
+
+// PointerMoveAction returns an instant pointer move to viewport coordinates.
+func PointerMoveAction(x, y int) Action {
+	return Action{Type: "pointerMove", X: x, Y: y}
+}
+
+// ScrollAction returns a wheel scroll at viewport coordinates.
+func ScrollAction(x, y, deltaX, deltaY int) Action {
+	return Action{Type: "scroll", X: x, Y: y, DeltaX: deltaX, DeltaY: deltaY}
+}
+
+// WheelActions returns a wheel source with the given actions.
+func WheelActions(actions ...Action) SourceActions {
+	return SourceActions{
+		Type:    "wheel",
+		ID:      "wheel",
+		Actions: actions,
+	}
+}
+
+// MouseActions returns a mouse pointer source with the given actions.
+func MouseActions(actions ...Action) SourceActions {
+	return SourceActions{
+		Type:       "pointer",
+		ID:         "mouse",
+		Parameters: &PointerParameters{PointerType: "mouse"},
+		Actions:    actions,
+	}
+}
+
+// KeyboardActions returns a key source with the given actions.
+func KeyboardActions(actions ...Action) SourceActions {
+	return SourceActions{
+		Type:    "key",
+		ID:      "keyboard",
+		Actions: actions,
+	}
+}
+
 // PerformActions executes a sequence of input actions.
-func (c *Client) PerformActions(context string, actions []map[string]interface{}) error {
+// If context is empty, it uses the first available context.
+func (c *Client) PerformActions(context string, actions []SourceActions) error {
 	// If no context provided, get the first one from the tree
 	if context == "" {
 		tree, err := c.GetTree()
@@ -27,82 +168,284 @@ func (c *Client) PerformActions(context string, actions []map[string]interface{}
 	return err
 }
 
-// Click performs a mouse click at the specified coordinates.
-func (c *Client) Click(context string, x, y float64) error {
-	actions := []map[string]interface{}{
-		{
-			"type": "pointer",
-			"id":   "mouse",
-			"parameters": map[string]interface{}{
-				"pointerType": "mouse",
-			},
-			"actions": []map[string]interface{}{
-				{
-					"type":     "pointerMove",
-					"x":        int(x),
-					"y":        int(y),
-					"duration": 0,
-				},
-				{
-					"type":   "pointerDown",
-					"button": 0,
-				},
-				{
-					"type":   "pointerUp",
-					"button": 0,
-				},
-			},
-		},
+// ReleaseActions releases all pressed keys and buttons and resets the input
+// state of a context. It is safe to call when no actions are pending.
+// If context is empty, it uses the first available context.
+func (c *Client) ReleaseActions(context string) error {
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	params := map[string]interface{}{
+		"context": context,
+	}
+
+	_, err := c.SendCommand("input.releaseActions", params)
+	return err
+}
+
+// ClickAt performs a mouse click at the specified coordinates.
+func (c *Client) ClickAt(context string, x, y float64) error {
+	actions := []SourceActions{
+		MouseActions(
+			PointerMoveAction(int(x), int(y)),
+			PointerDownAction(0),
+			PointerUpAction(0),
+		),
 	}
 
 	return c.PerformActions(context, actions)
 }
 
-// ClickElement finds an element and clicks its center.
-func (c *Client) ClickElement(context, selector string) error {
-	info, err := c.FindElement(context, selector)
+// ClickOpts configures ClickWithOpts.
+type ClickOpts struct {
+	// RequireVisible fails the click if the element is not visible.
+	RequireVisible bool
+}
+
+// Click finds the first element matching a CSS selector, scrolls it into
+// view and clicks its center.
+// If context is empty, it uses the first available context.
+func (c *Client) Click(context, selector string) error {
+	return c.ClickWithOpts(context, selector, ClickOpts{})
+}
+
+// ClickWithOpts is Click with options.
+// If context is empty, it uses the first available context.
+func (c *Client) ClickWithOpts(context, selector string, opts ClickOpts) error {
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	node, err := c.locateFirst(context, selector)
 	if err != nil {
 		return err
 	}
 
-	x, y := info.GetCenter()
-	return c.Click(context, x, y)
+	if opts.RequireVisible {
+		visible, err := c.IsVisible(context, node)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			return fmt.Errorf("element %s is not visible", selector)
+		}
+	}
+
+	rect, err := c.scrollIntoViewRect(context, node)
+	if err != nil {
+		return err
+	}
+
+	x, y := rect.Center()
+	return c.ClickAt(context, x, y)
+}
+
+// locateFirst returns the first node matching a CSS selector.
+func (c *Client) locateFirst(context, selector string) (*RemoteValue, error) {
+	nodes, err := c.LocateNodesWithOpts(context, CSSLocator(selector), LocateNodesOpts{MaxNodeCount: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, &errs.ElementNotFoundError{Selector: selector, Context: context}
+	}
+	return &nodes[0], nil
+}
+
+// scrollIntoView scrolls a node until it is centered in the viewport.
+func (c *Client) scrollIntoView(context string, node *RemoteValue) error {
+	script := `(el) => el.scrollIntoView({ block: 'center', inline: 'center' })`
+	if _, err := c.CallFunction(context, script, []interface{}{node}); err != nil {
+		return fmt.Errorf("failed to scroll element into view: %w", err)
+	}
+	return nil
+}
+
+// scrollIntoViewRect scrolls a node into view and returns its bounding
+// rectangle in viewport coordinates.
+func (c *Client) scrollIntoViewRect(context string, node *RemoteValue) (Rect, error) {
+	if err := c.scrollIntoView(context, node); err != nil {
+		return Rect{}, err
+	}
+	return c.GetBoundingRect(context, node)
+}
+
+// DefaultDragSteps is the number of intermediate pointer moves used by DragAndDrop.
+const DefaultDragSteps = 10
+
+// DragAndDrop drags the first element matching source onto the first element
+// matching target, using DefaultDragSteps intermediate moves.
+// If context is empty, it uses the first available context.
+func (c *Client) DragAndDrop(context, source, target string) error {
+	return c.DragAndDropSteps(context, source, target, DefaultDragSteps)
+}
+
+// DragAndDropSteps drags the first element matching source onto the first
+// element matching target. The pointer moves in steps intermediate moves,
+// since many HTML5 drag and drop implementations ignore a pointer that jumps
+// straight to the drop target.
+// If context is empty, it uses the first available context.
+func (c *Client) DragAndDropSteps(context, source, target string, steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("drag steps must be at least 1, got %d", steps)
+	}
+
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	sourceNode, err := c.locateFirst(context, source)
+	if err != nil {
+		return err
+	}
+	targetNode, err := c.locateFirst(context, target)
+	if err != nil {
+		return err
+	}
+
+	// Scroll the source first; the target box is read afterwards so both
+	// positions are in the same scroll state
+	sourceRect, err := c.scrollIntoViewRect(context, sourceNode)
+	if err != nil {
+		return err
+	}
+	targetRect, err := c.GetBoundingRect(context, targetNode)
+	if err != nil {
+		return err
+	}
+
+	startX, startY := sourceRect.Center()
+	endX, endY := targetRect.Center()
+
+	pointerActions := []Action{
+		PointerMoveAction(int(startX), int(startY)),
+		PointerDownAction(0),
+	}
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := startX + (endX-startX)*t
+		y := startY + (endY-startY)*t
+		pointerActions = append(pointerActions, PointerMoveAction(int(x), int(y)))
+	}
+	pointerActions = append(pointerActions, PointerUpAction(0))
+
+	return c.PerformActions(context, []SourceActions{MouseActions(pointerActions...)})
+}
+
+// SetFiles sets the files of an <input type=file> element. Paths must be
+// absolute and exist on the machine running the browser; they are checked
+// locally before sending. An empty files list clears the input.
+// If context is empty, it uses the first available context.
+func (c *Client) SetFiles(context string, element *RemoteValue, files []string) error {
+	if element == nil || element.SharedID == "" {
+		return fmt.Errorf("element has no sharedId")
+	}
+
+	for _, file := range files {
+		if !filepath.IsAbs(file) {
+			return fmt.Errorf("file path must be absolute: %s", file)
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("cannot set file %s: %w", file, err)
+		}
+	}
+
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	if files == nil {
+		files = []string{}
+	}
+
+	params := map[string]interface{}{
+		"context": context,
+		"element": map[string]interface{}{"sharedId": element.SharedID},
+		"files":   files,
+	}
+
+	_, err := c.SendCommand("input.setFiles", params)
+	return err
+}
+
+// Scroll dispatches a mouse wheel event at viewport coordinates (x, y),
+// scrolling by deltaX and deltaY pixels.
+// If context is empty, it uses the first available context.
+func (c *Client) Scroll(context string, x, y, deltaX, deltaY int) error {
+	actions := []SourceActions{
+		WheelActions(ScrollAction(x, y, deltaX, deltaY)),
+	}
+
+	return c.PerformActions(context, actions)
+}
+
+// ScrollElement dispatches a mouse wheel event at an offset (x, y) from the
+// center of an element, scrolling by deltaX and deltaY pixels.
+// If context is empty, it uses the first available context.
+func (c *Client) ScrollElement(context string, element *RemoteValue, x, y, deltaX, deltaY int) error {
+	if element == nil || element.SharedID == "" {
+		return fmt.Errorf("element has no sharedId")
+	}
+
+	scroll := ScrollAction(x, y, deltaX, deltaY)
+	scroll.Element = element
+
+	return c.PerformActions(context, []SourceActions{WheelActions(scroll)})
+}
+
+// ScrollIntoView scrolls the page until an element is centered in the viewport.
+// If context is empty, it uses the first available context.
+func (c *Client) ScrollIntoView(context string, element *RemoteValue) error {
+	if element == nil || element.SharedID == "" {
+		return fmt.Errorf("element has no sharedId")
+	}
+
+	return c.scrollIntoView(context, element)
 }
 
 // DoubleClick performs a double-click at the specified coordinates.
 func (c *Client) DoubleClick(context string, x, y float64) error {
-	actions := []map[string]interface{}{
-		{
-			"type": "pointer",
-			"id":   "mouse",
-			"parameters": map[string]interface{}{
-				"pointerType": "mouse",
-			},
-			"actions": []map[string]interface{}{
-				{
-					"type":     "pointerMove",
-					"x":        int(x),
-					"y":        int(y),
-					"duration": 0,
-				},
-				{
-					"type":   "pointerDown",
-					"button": 0,
-				},
-				{
-					"type":   "pointerUp",
-					"button": 0,
-				},
-				{
-					"type":   "pointerDown",
-					"button": 0,
-				},
-				{
-					"type":   "pointerUp",
-					"button": 0,
-				},
-			},
-		},
+	actions := []SourceActions{
+		MouseActions(
+			PointerMoveAction(int(x), int(y)),
+			PointerDownAction(0),
+			PointerUpAction(0),
+			PointerDownAction(0),
+			PointerUpAction(0),
+		),
 	}
 
 	return c.PerformActions(context, actions)
@@ -110,83 +453,72 @@ func (c *Client) DoubleClick(context string, x, y float64) error {
 
 // MoveMouse moves the mouse to the specified coordinates.
 func (c *Client) MoveMouse(context string, x, y float64) error {
-	actions := []map[string]interface{}{
-		{
-			"type": "pointer",
-			"id":   "mouse",
-			"parameters": map[string]interface{}{
-				"pointerType": "mouse",
-			},
-			"actions": []map[string]interface{}{
-				{
-					"type":     "pointerMove",
-					"x":        int(x),
-					"y":        int(y),
-					"duration": 0,
-				},
-			},
-		},
+	actions := []SourceActions{
+		MouseActions(PointerMoveAction(int(x), int(y))),
 	}
 
 	return c.PerformActions(context, actions)
 }
 
-// TypeText types a string of text using keyboard events.
-func (c *Client) TypeText(context, text string) error {
-	// Build key actions for each character
-	keyActions := make([]map[string]interface{}, 0, len(text)*2)
+// WebDriver key values for special keys.
+const (
+	KeyTab   = "\uE004"
+	KeyEnter = "\uE007"
+	KeyShift = "\uE008"
+)
+
+// shiftedChars are the characters typed with Shift held on a US keyboard.
+const shiftedChars = `~!@#$%^&*()_+{}|:"<>?`
+
+// keyActionsFor returns the key actions that type a string. Newlines and tabs
+// become Enter and Tab presses, and characters that need Shift are typed
+// with Shift held.
+func keyActionsFor(text string) []Action {
+	actions := make([]Action, 0, len(text)*2)
 	for _, char := range text {
-		keyActions = append(keyActions,
-			map[string]interface{}{
-				"type": "keyDown",
-				"value": string(char),
-			},
-			map[string]interface{}{
-				"type": "keyUp",
-				"value": string(char),
-			},
-		)
-	}
-
-	actions := []map[string]interface{}{
-		{
-			"type":    "key",
-			"id":      "keyboard",
-			"actions": keyActions,
-		},
+		key := string(char)
+		switch char {
+		case '\n':
+			key = KeyEnter
+		case '\t':
+			key = KeyTab
+		}
+
+		shifted := unicode.IsUpper(char) || strings.ContainsRune(shiftedChars, char)
+		if shifted {
+			actions = append(actions, KeyDownAction(KeyShift))
+		}
+		actions = append(actions, KeyDownAction(key), KeyUpAction(key))
+		if shifted {
+			actions = append(actions, KeyUpAction(KeyShift))
+		}
 	}
+	return actions
+}
+
+// SendKeys types a string into whatever element currently has focus.
+func (c *Client) SendKeys(context, keys string) error {
+	actions := []SourceActions{KeyboardActions(keyActionsFor(keys)...)}
 
 	return c.PerformActions(context, actions)
 }
 
-// TypeIntoElement clicks an element and types text into it.
-func (c *Client) TypeIntoElement(context, selector, text string) error {
+// Type clicks the first element matching a CSS selector to focus it, then
+// types text into it.
+func (c *Client) Type(context, selector, text string) error {
 	// Click the element first to focus it
-	if err := c.ClickElement(context, selector); err != nil {
+	if err := c.Click(context, selector); err != nil {
 		return fmt.Errorf("failed to click element: %w", err)
 	}
 
 	// Type the text
-	return c.TypeText(context, text)
+	return c.SendKeys(context, text)
 }
 
 // PressKey presses a single key (for special keys like Enter, Tab, etc).
 func (c *Client) PressKey(context, key string) error {
-	actions := []map[string]interface{}{
-		{
-			"type": "key",
-			"id":   "keyboard",
-			"actions": []map[string]interface{}{
-				{
-					"type":  "keyDown",
-					"value": key,
-				},
-				{
-					"type":  "keyUp",
-					"value": key,
-				},
-			},
-		},
+	actions := []SourceActions{
+		KeyboardActions(KeyDownAction(key), KeyUpAction(key)),
 	}
 
 	return c.PerformActions(context, actions)
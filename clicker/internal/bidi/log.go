@@ -0,0 +1,146 @@
+package bidi
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Log levels reported in LogEntry.Level.
+const (
+	LogDebug = "debug"
+	LogInfo  = "info"
+	LogWarn  = "warn"
+	LogError = "error"
+)
+
+// LogEntry is a console message or uncaught page error. Type is "console"
+// for console API calls and "javascript" for uncaught errors.
+type LogEntry struct {
+	Type       string
+	Level      string
+	Source     MessageSource
+	Text       string
+	Timestamp  int64 // milliseconds since the epoch
+	StackTrace *StackTrace
+	Method     string        // console method, e.g. "log" or "error"
+	Args       []interface{} // decoded console arguments
+}
+
+// OnLogEntry subscribes to log.entryAdded events.
+func (c *Client) OnLogEntry(handler func(LogEntry)) error {
+	return c.subscribeHandler("log.entryAdded", nil, func(params json.RawMessage) {
+		entry, err := parseLogEntry(params)
+		if err != nil {
+			return
+		}
+		handler(entry)
+	})
+}
+
+// parseLogEntry decodes log.entryAdded params.
+func parseLogEntry(params json.RawMessage) (LogEntry, error) {
+	var event struct {
+		Type       string            `json:"type"`
+		Level      string            `json:"level"`
+		Source     MessageSource     `json:"source"`
+		Text       *string           `json:"text"`
+		Timestamp  int64             `json:"timestamp"`
+		StackTrace *StackTrace       `json:"stackTrace"`
+		Method     string            `json:"method"`
+		Args       []json.RawMessage `json:"args"`
+	}
+	if err := json.Unmarshal(params, &event); err != nil {
+		return LogEntry{}, err
+	}
+
+	entry := LogEntry{
+		Type:       event.Type,
+		Level:      event.Level,
+		Source:     event.Source,
+		Timestamp:  event.Timestamp,
+		StackTrace: event.StackTrace,
+		Method:     event.Method,
+	}
+	if event.Text != nil {
+		entry.Text = *event.Text
+	}
+
+	for _, arg := range event.Args {
+		v, err := decodeRemoteValue(arg)
+		if err != nil {
+			return LogEntry{}, err
+		}
+		entry.Args = append(entry.Args, v)
+	}
+
+	return entry, nil
+}
+
+// ConsoleRecorder accumulates log entries for later inspection.
+type ConsoleRecorder struct {
+	client    *Client
+	handlerID int64
+
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+// RecordConsole starts recording log entries. The handler is registered
+// before subscribing, so no entry delivered after the call returns is lost.
+func (c *Client) RecordConsole() (*ConsoleRecorder, error) {
+	r := &ConsoleRecorder{client: c}
+	r.handlerID = c.addHandler("log.entryAdded", func(params json.RawMessage) {
+		entry, err := parseLogEntry(params)
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		r.entries = append(r.entries, entry)
+		r.mu.Unlock()
+	})
+
+	if err := c.Subscribe([]string{"log.entryAdded"}, nil); err != nil {
+		c.removeHandler("log.entryAdded", r.handlerID)
+		return nil, err
+	}
+	return r, nil
+}
+
+// Entries returns a copy of all recorded entries.
+func (r *ConsoleRecorder) Entries() []LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]LogEntry(nil), r.entries...)
+}
+
+// Level returns the recorded entries with the given level.
+func (r *ConsoleRecorder) Level(level string) []LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []LogEntry
+	for _, entry := range r.entries {
+		if entry.Level == level {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// Errors returns the recorded entries with level "error", which includes
+// uncaught page errors.
+func (r *ConsoleRecorder) Errors() []LogEntry {
+	return r.Level(LogError)
+}
+
+// Reset discards all recorded entries.
+func (r *ConsoleRecorder) Reset() {
+	r.mu.Lock()
+	r.entries = nil
+	r.mu.Unlock()
+}
+
+// Stop stops recording. Recorded entries remain available.
+func (r *ConsoleRecorder) Stop() {
+	r.client.removeHandler("log.entryAdded", r.handlerID)
+}
@@ -0,0 +1,619 @@
+package bidi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Intercept phases for AddIntercept.
+const (
+	PhaseBeforeRequestSent = "beforeRequestSent"
+	PhaseResponseStarted   = "responseStarted"
+	PhaseAuthRequired      = "authRequired"
+)
+
+// URLPattern matches request URLs for network intercepts. A "string" pattern
+// matches a URL exactly; a "pattern" pattern matches the components that
+// are set and treats the rest as wildcards.
+type URLPattern struct {
+	Type     string `json:"type"` // "string" or "pattern"
+	Pattern  string `json:"pattern,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	Port     string `json:"port,omitempty"`
+	Pathname string `json:"pathname,omitempty"`
+	Search   string `json:"search,omitempty"`
+
+	// globs are partial wildcards from ParseURLPattern that the browser
+	// cannot match; MatchURL checks them
+	globs []componentGlob
+}
+
+// InterceptOpts configures AddIntercept.
+type InterceptOpts struct {
+	Phases      []string     // at least one of the Phase* constants
+	URLPatterns []URLPattern // empty matches every URL
+	Contexts    []string     // top-level contexts; empty means all
+}
+
+// AddInterceptResult represents the result of network.addIntercept.
+type AddInterceptResult struct {
+	Intercept string `json:"intercept"`
+}
+
+// AddIntercept pauses matching requests in the given phases so they can be
+// continued, failed or answered, and returns the intercept id.
+func (c *Client) AddIntercept(opts InterceptOpts) (string, error) {
+	if len(opts.Phases) == 0 {
+		return "", fmt.Errorf("at least one intercept phase is required")
+	}
+	for _, phase := range opts.Phases {
+		switch phase {
+		case PhaseBeforeRequestSent, PhaseResponseStarted, PhaseAuthRequired:
+		default:
+			return "", fmt.Errorf("invalid intercept phase: %s", phase)
+		}
+	}
+
+	for _, pattern := range opts.URLPatterns {
+		if err := pattern.Validate(); err != nil {
+			return "", err
+		}
+	}
+
+	params := map[string]interface{}{
+		"phases": opts.Phases,
+	}
+	if len(opts.URLPatterns) > 0 {
+		params["urlPatterns"] = opts.URLPatterns
+	}
+	if len(opts.Contexts) > 0 {
+		params["contexts"] = opts.Contexts
+	}
+
+	msg, err := c.SendCommand("network.addIntercept", params)
+	if err != nil {
+		return "", err
+	}
+
+	var result AddInterceptResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return "", fmt.Errorf("failed to parse network.addIntercept result: %w", err)
+	}
+
+	return result.Intercept, nil
+}
+
+// RemoveIntercept removes an intercept added with AddIntercept.
+func (c *Client) RemoveIntercept(intercept string) error {
+	if intercept == "" {
+		return fmt.Errorf("intercept id is required")
+	}
+
+	params := map[string]interface{}{
+		"intercept": intercept,
+	}
+
+	_, err := c.SendCommand("network.removeIntercept", params)
+	return err
+}
+
+// BytesValue is a header value, cookie value or body. Type is "string" for
+// UTF-8 text or "base64" for binary data.
+type BytesValue struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// StringValue returns a text BytesValue.
+func StringValue(s string) BytesValue {
+	return BytesValue{Type: "string", Value: s}
+}
+
+// Base64Value returns a BytesValue holding base64-encoded binary data.
+func Base64Value(data []byte) BytesValue {
+	return BytesValue{Type: "base64", Value: base64.StdEncoding.EncodeToString(data)}
+}
+
+// Bytes returns the decoded contents of the value.
+func (v BytesValue) Bytes() ([]byte, error) {
+	if v.Type == "base64" {
+		return base64.StdEncoding.DecodeString(v.Value)
+	}
+	return []byte(v.Value), nil
+}
+
+// Header is an HTTP header.
+type Header struct {
+	Name  string     `json:"name"`
+	Value BytesValue `json:"value"`
+}
+
+// CookieHeader is a cookie sent in a request Cookie header.
+type CookieHeader struct {
+	Name  string     `json:"name"`
+	Value BytesValue `json:"value"`
+}
+
+// ContinueRequestOpts configures ContinueRequest. Nil or empty fields keep the
+// original request's values.
+type ContinueRequestOpts struct {
+	Request string // request id from a network.beforeRequestSent event
+	URL     string
+	Method  string
+	Headers []Header       // replaces all headers when non-nil
+	Cookies []CookieHeader // replaces all cookies when non-nil
+	Body    *BytesValue
+}
+
+// ContinueRequest resumes a request paused in the beforeRequestSent phase,
+// optionally overriding parts of it.
+func (c *Client) ContinueRequest(opts ContinueRequestOpts) error {
+	if opts.Request == "" {
+		return fmt.Errorf("request id is required")
+	}
+
+	params := map[string]interface{}{
+		"request": opts.Request,
+	}
+	if opts.URL != "" {
+		params["url"] = opts.URL
+	}
+	if opts.Method != "" {
+		params["method"] = opts.Method
+	}
+	if opts.Headers != nil {
+		params["headers"] = opts.Headers
+	}
+	if opts.Cookies != nil {
+		params["cookies"] = opts.Cookies
+	}
+	if opts.Body != nil {
+		params["body"] = opts.Body
+	}
+
+	_, err := c.SendCommand("network.continueRequest", params)
+	return err
+}
+
+// ProvideResponseOpts configures ProvideResponse. Zero-valued fields keep the
+// values of the original response, if there is one.
+type ProvideResponseOpts struct {
+	Request      string // request id from an intercepted network event
+	StatusCode   int
+	ReasonPhrase string
+	Headers      []Header // replaces all headers when non-nil
+	Body         []byte   // sent base64-encoded; nil keeps the original body
+}
+
+// ProvideResponse answers an intercepted request with a canned response,
+// without sending it to the network.
+func (c *Client) ProvideResponse(opts ProvideResponseOpts) error {
+	if opts.Request == "" {
+		return fmt.Errorf("request id is required")
+	}
+
+	params := map[string]interface{}{
+		"request": opts.Request,
+	}
+	if opts.StatusCode != 0 {
+		params["statusCode"] = opts.StatusCode
+	}
+	if opts.ReasonPhrase != "" {
+		params["reasonPhrase"] = opts.ReasonPhrase
+	}
+	if opts.Headers != nil {
+		params["headers"] = opts.Headers
+	}
+	if opts.Body != nil {
+		params["body"] = Base64Value(opts.Body)
+	}
+
+	_, err := c.SendCommand("network.provideResponse", params)
+	return err
+}
+
+// FailRequest aborts an intercepted request with a network error. The browser
+// returns an error if the request is not paused in an interceptable phase.
+func (c *Client) FailRequest(requestID string) error {
+	if requestID == "" {
+		return fmt.Errorf("request id is required")
+	}
+
+	params := map[string]interface{}{
+		"request": requestID,
+	}
+
+	_, err := c.SendCommand("network.failRequest", params)
+	return err
+}
+
+// AuthCredentials are HTTP authentication credentials.
+type AuthCredentials struct {
+	Username string
+	Password string
+}
+
+// serialize returns the BiDi network.AuthCredentials form.
+func (a *AuthCredentials) serialize() map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "password",
+		"username": a.Username,
+		"password": a.Password,
+	}
+}
+
+// ContinueResponseOpts configures ContinueResponse. Nil or zero-valued fields
+// pass the original response through unchanged.
+type ContinueResponseOpts struct {
+	Request      string // request id from a network.responseStarted event
+	StatusCode   int
+	ReasonPhrase string
+	Headers      []Header // replaces all headers when non-nil
+	Credentials  *AuthCredentials
+}
+
+// ContinueResponse resumes a response paused in the responseStarted or
+// authRequired phase, optionally overriding its status and headers.
+func (c *Client) ContinueResponse(opts ContinueResponseOpts) error {
+	if opts.Request == "" {
+		return fmt.Errorf("request id is required")
+	}
+
+	params := map[string]interface{}{
+		"request": opts.Request,
+	}
+	if opts.StatusCode != 0 {
+		params["statusCode"] = opts.StatusCode
+	}
+	if opts.ReasonPhrase != "" {
+		params["reasonPhrase"] = opts.ReasonPhrase
+	}
+	if opts.Headers != nil {
+		params["headers"] = opts.Headers
+	}
+	if opts.Credentials != nil {
+		params["credentials"] = opts.Credentials.serialize()
+	}
+
+	_, err := c.SendCommand("network.continueResponse", params)
+	return err
+}
+
+// AuthAction is the response to an HTTP authentication challenge.
+type AuthAction string
+
+const (
+	AuthProvideCredentials AuthAction = "provideCredentials" // answer with username and password
+	AuthDefault            AuthAction = "default"            // let the browser handle it
+	AuthCancel             AuthAction = "cancel"             // cancel the challenge
+)
+
+// ContinueWithAuth answers an authentication challenge for a request paused
+// in the authRequired phase. Username and password are only sent with
+// AuthProvideCredentials.
+func (c *Client) ContinueWithAuth(requestID string, action AuthAction, username, password string) error {
+	if requestID == "" {
+		return fmt.Errorf("request id is required")
+	}
+
+	params := map[string]interface{}{
+		"request": requestID,
+		"action":  string(action),
+	}
+
+	switch action {
+	case AuthProvideCredentials:
+		creds := &AuthCredentials{Username: username, Password: password}
+		params["credentials"] = creds.serialize()
+	case AuthDefault, AuthCancel:
+	default:
+		return fmt.Errorf("invalid auth action: %s", action)
+	}
+
+	_, err := c.SendCommand("network.continueWithAuth", params)
+	return err
+}
+
+// Cookie is a browser cookie.
+type Cookie struct {
+	Name     string     `json:"name"`
+	Value    BytesValue `json:"value"`
+	Domain   string     `json:"domain"`
+	Path     string     `json:"path"`
+	Size     int        `json:"size"`
+	HTTPOnly bool       `json:"httpOnly"`
+	Secure   bool       `json:"secure"`
+	SameSite string     `json:"sameSite"`         // "strict", "lax" or "none"
+	Expiry   *int64     `json:"expiry,omitempty"` // unix seconds; nil for session cookies
+}
+
+// FetchTimingInfo holds request timings in milliseconds relative to TimeOrigin.
+type FetchTimingInfo struct {
+	TimeOrigin    float64 `json:"timeOrigin"`
+	RequestTime   float64 `json:"requestTime"`
+	RedirectStart float64 `json:"redirectStart"`
+	RedirectEnd   float64 `json:"redirectEnd"`
+	FetchStart    float64 `json:"fetchStart"`
+	DNSStart      float64 `json:"dnsStart"`
+	DNSEnd        float64 `json:"dnsEnd"`
+	ConnectStart  float64 `json:"connectStart"`
+	ConnectEnd    float64 `json:"connectEnd"`
+	TLSStart      float64 `json:"tlsStart"`
+	RequestStart  float64 `json:"requestStart"`
+	ResponseStart float64 `json:"responseStart"`
+	ResponseEnd   float64 `json:"responseEnd"`
+}
+
+// RequestData describes a network request.
+type RequestData struct {
+	Request       string          `json:"request"` // request id
+	URL           string          `json:"url"`
+	Method        string          `json:"method"`
+	Headers       []Header        `json:"headers"`
+	Cookies       []Cookie        `json:"cookies"`
+	HeadersSize   int64           `json:"headersSize"`
+	BodySize      *int64          `json:"bodySize"`
+	Destination   string          `json:"destination"`
+	InitiatorType string          `json:"initiatorType"`
+	Timings       FetchTimingInfo `json:"timings"`
+}
+
+// ResponseContent describes a response body.
+type ResponseContent struct {
+	Size int64 `json:"size"`
+}
+
+// AuthChallenge is a WWW-Authenticate challenge from a response.
+type AuthChallenge struct {
+	Scheme string `json:"scheme"`
+	Realm  string `json:"realm"`
+}
+
+// ResponseData describes a network response.
+type ResponseData struct {
+	URL            string          `json:"url"`
+	Protocol       string          `json:"protocol"`
+	Status         int             `json:"status"`
+	StatusText     string          `json:"statusText"`
+	FromCache      bool            `json:"fromCache"`
+	Headers        []Header        `json:"headers"`
+	MimeType       string          `json:"mimeType"`
+	BytesReceived  int64           `json:"bytesReceived"`
+	HeadersSize    *int64          `json:"headersSize"`
+	BodySize       *int64          `json:"bodySize"`
+	Content        ResponseContent `json:"content"`
+	AuthChallenges []AuthChallenge `json:"authChallenges,omitempty"`
+}
+
+// Initiator describes what caused a request.
+type Initiator struct {
+	Type         string      `json:"type,omitempty"`
+	ColumnNumber int         `json:"columnNumber,omitempty"`
+	LineNumber   int         `json:"lineNumber,omitempty"`
+	StackTrace   *StackTrace `json:"stackTrace,omitempty"`
+	Request      string      `json:"request,omitempty"`
+}
+
+// NetworkEvent holds the fields shared by all network events. Redirects reuse
+// the request id with an incremented RedirectCount.
+type NetworkEvent struct {
+	Context       string      `json:"context"`
+	IsBlocked     bool        `json:"isBlocked"` // paused by an intercept
+	Navigation    string      `json:"navigation"`
+	RedirectCount int         `json:"redirectCount"`
+	Request       RequestData `json:"request"`
+	Timestamp     int64       `json:"timestamp"` // milliseconds since the epoch
+	Intercepts    []string    `json:"intercepts,omitempty"`
+}
+
+// RequestEvent is delivered with network.beforeRequestSent.
+type RequestEvent struct {
+	NetworkEvent
+	Initiator *Initiator `json:"initiator,omitempty"`
+}
+
+// ResponseEvent is delivered with network.responseStarted and network.responseCompleted.
+type ResponseEvent struct {
+	NetworkEvent
+	Response ResponseData `json:"response"`
+}
+
+// OnBeforeRequestSent subscribes to network.beforeRequestSent events.
+func (c *Client) OnBeforeRequestSent(handler func(RequestEvent)) error {
+	return c.subscribeHandler("network.beforeRequestSent", nil, func(params json.RawMessage) {
+		var event RequestEvent
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+		handler(event)
+	})
+}
+
+// OnResponseStarted subscribes to network.responseStarted events, fired when
+// response headers arrive.
+func (c *Client) OnResponseStarted(handler func(ResponseEvent)) error {
+	return c.onResponseEvent("network.responseStarted", handler)
+}
+
+// OnResponseCompleted subscribes to network.responseCompleted events, fired
+// when the full response body has been received.
+func (c *Client) OnResponseCompleted(handler func(ResponseEvent)) error {
+	return c.onResponseEvent("network.responseCompleted", handler)
+}
+
+// onResponseEvent registers a handler for an event carrying a ResponseEvent.
+func (c *Client) onResponseEvent(method string, handler func(ResponseEvent)) error {
+	return c.subscribeHandler(method, nil, func(params json.RawMessage) {
+		var event ResponseEvent
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+		handler(event)
+	})
+}
+
+// Cache behaviors for SetCacheBehavior.
+const (
+	CacheDefault = "default"
+	CacheBypass  = "bypass"
+)
+
+// SetCacheBehavior sets whether the HTTP cache is used.
+// If contexts is empty, the behavior applies globally.
+func (c *Client) SetCacheBehavior(behavior string, contexts []string) error {
+	switch behavior {
+	case CacheDefault, CacheBypass:
+	default:
+		return fmt.Errorf("invalid cache behavior: %s", behavior)
+	}
+
+	params := map[string]interface{}{
+		"cacheBehavior": behavior,
+	}
+	if len(contexts) > 0 {
+		params["contexts"] = contexts
+	}
+
+	_, err := c.SendCommand("network.setCacheBehavior", params)
+	return err
+}
+
+// AddDataCollectorResult represents the result of network.addDataCollector.
+type AddDataCollectorResult struct {
+	Collector string `json:"collector"`
+}
+
+// AddDataCollector starts retaining response bodies of up to maxSize bytes
+// so they can be read with GetResponseBody, and returns the collector id.
+// If contexts is empty, bodies are collected for all contexts.
+func (c *Client) AddDataCollector(maxSize int, contexts []string) (string, error) {
+	if maxSize <= 0 {
+		return "", fmt.Errorf("max size must be positive: %d", maxSize)
+	}
+
+	params := map[string]interface{}{
+		"dataTypes":          []string{"response"},
+		"maxEncodedDataSize": maxSize,
+	}
+	if len(contexts) > 0 {
+		params["contexts"] = contexts
+	}
+
+	msg, err := c.SendCommand("network.addDataCollector", params)
+	if err != nil {
+		return "", err
+	}
+
+	var result AddDataCollectorResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return "", fmt.Errorf("failed to parse network.addDataCollector result: %w", err)
+	}
+
+	return result.Collector, nil
+}
+
+// RemoveDataCollector stops a collector added with AddDataCollector.
+func (c *Client) RemoveDataCollector(collector string) error {
+	params := map[string]interface{}{
+		"collector": collector,
+	}
+
+	_, err := c.SendCommand("network.removeDataCollector", params)
+	return err
+}
+
+// GetDataResult represents the result of network.getData.
+type GetDataResult struct {
+	Bytes BytesValue `json:"bytes"`
+}
+
+// getResponseData fetches the collected response body for a request.
+func (c *Client) getResponseData(requestID string) (*BytesValue, error) {
+	if requestID == "" {
+		return nil, fmt.Errorf("request id is required")
+	}
+
+	params := map[string]interface{}{
+		"dataType": "response",
+		"request":  requestID,
+	}
+
+	msg, err := c.SendCommand("network.getData", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result GetDataResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse network.getData result: %w", err)
+	}
+
+	return &result.Bytes, nil
+}
+
+// GetResponseBody returns the body of a completed response. A data
+// collector must have been added with AddDataCollector before the request
+// was made.
+func (c *Client) GetResponseBody(requestID string) ([]byte, error) {
+	body, err := c.getResponseData(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := body.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	}
+	return data, nil
+}
+
+// WriteResponseBody streams the body of a completed response to w, decoding
+// base64 bodies incrementally instead of allocating a second copy.
+func (c *Client) WriteResponseBody(requestID string, w io.Writer) error {
+	body, err := c.getResponseData(requestID)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = strings.NewReader(body.Value)
+	if body.Type == "base64" {
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("failed to write response body: %w", err)
+	}
+	return nil
+}
+
+// SetExtraHeaders adds headers to every request made by a context, replacing
+// headers set by a previous call for the same context. Empty headers remove
+// them. If context is empty, the headers apply to all contexts. Requests
+// paused by an intercept still carry the extra headers.
+func (c *Client) SetExtraHeaders(context string, headers map[string]string) error {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]Header, len(names))
+	for i, name := range names {
+		list[i] = Header{Name: name, Value: StringValue(headers[name])}
+	}
+
+	params := map[string]interface{}{
+		"headers": list,
+	}
+	if context != "" {
+		params["contexts"] = []string{context}
+	}
+
+	_, err := c.SendCommand("network.setExtraHeaders", params)
+	return err
+}
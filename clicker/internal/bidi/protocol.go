@@ -1,17 +1,6 @@
 package bidi
 
-import (
-	"encoding/json"
-	"sync/atomic"
-)
-
-// commandID is an atomic counter for generating unique command IDs.
-var commandID int64
-
-// NextID returns the next unique command ID.
-func NextID() int64 {
-	return atomic.AddInt64(&commandID, 1)
-}
+import "encoding/json"
 
 // Command represents a BiDi command to be sent to the browser.
 type Command struct {
@@ -29,8 +18,9 @@ type Response struct {
 
 // ErrorData represents an error in a BiDi response.
 type ErrorData struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
+	Error      string `json:"error"`
+	Message    string `json:"message"`
+	Stacktrace string `json:"stacktrace,omitempty"`
 }
 
 // Event represents a BiDi event from the browser.
@@ -40,12 +30,20 @@ type Event struct {
 }
 
 // Message is a generic BiDi message that can be either a response or event.
+// SendCommand returns the response to a command as a Message, so commands
+// this package does not wrap can be sent directly and their Result decoded
+// by the caller. Error responses are returned as *errors.BiDiError instead.
 type Message struct {
 	// Response fields
 	ID     *int64          `json:"id,omitempty"`
+	Type   string          `json:"type,omitempty"` // "success", "error" or "event"
 	Result json.RawMessage `json:"result,omitempty"`
 	Error  json.RawMessage `json:"error,omitempty"`
 
+	// Error response fields (alongside the error code in Error)
+	ErrorMessage string `json:"message,omitempty"`
+	Stacktrace   string `json:"stacktrace,omitempty"`
+
 	// Event fields
 	Method string          `json:"method,omitempty"`
 	Params json.RawMessage `json:"params,omitempty"`
@@ -72,28 +70,21 @@ func (m *Message) GetError() (*ErrorData, error) {
 		return nil, nil
 	}
 
-	// Try to unmarshal as ErrorData object
+	// Per the spec the error code is a string, with message and stacktrace
+	// as sibling fields
+	var errStr string
+	if err := json.Unmarshal(m.Error, &errStr); err == nil {
+		return &ErrorData{Error: errStr, Message: m.ErrorMessage, Stacktrace: m.Stacktrace}, nil
+	}
+
+	// Some implementations nest the error as an object
 	var errData ErrorData
 	if err := json.Unmarshal(m.Error, &errData); err != nil {
-		// If that fails, it might be a plain string
-		var errStr string
-		if err := json.Unmarshal(m.Error, &errStr); err != nil {
-			return nil, err
-		}
-		return &ErrorData{Error: errStr, Message: errStr}, nil
+		return nil, err
 	}
 	return &errData, nil
 }
 
-// NewCommand creates a new BiDi command with a unique ID.
-func NewCommand(method string, params interface{}) *Command {
-	return &Command{
-		ID:     NextID(),
-		Method: method,
-		Params: params,
-	}
-}
-
 // Marshal serializes a command to JSON.
 func (c *Command) Marshal() ([]byte, error) {
 	return json.Marshal(c)
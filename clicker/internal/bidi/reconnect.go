@@ -0,0 +1,296 @@
+package bidi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errConnectionLost is returned to commands whose response was lost when the
+// connection dropped and the client reconnected.
+var errConnectionLost = errors.New("connection lost before response was received")
+
+// ReconnectOpts configures automatic reconnection. Zero fields use defaults.
+type ReconnectOpts struct {
+	BaseDelay   time.Duration // delay before the first attempt; default 100ms
+	MaxDelay    time.Duration // upper bound on the delay; default 5s
+	MaxAttempts int           // attempts before giving up; default 5
+
+	// NoResubscribe disables replaying subscriptions and preload scripts
+	// after a reconnect, leaving that to OnReconnect handlers.
+	NoResubscribe bool
+}
+
+// EnableReconnect makes the client reconnect to the same URL when its
+// WebSocket connection closes unexpectedly, doubling the delay after each
+// failed attempt. After reconnecting, active subscriptions are replayed and,
+// if a new session was started, preload scripts are re-added. Commands sent
+// while reconnecting wait for the new connection; commands that were
+// awaiting a response when the connection dropped fail. If every attempt
+// fails, all waiting commands fail with the last error.
+func (c *Client) EnableReconnect(opts ReconnectOpts) {
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = 100 * time.Millisecond
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 5 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+
+	c.connMu.Lock()
+	c.reconnect = &opts
+	c.connMu.Unlock()
+}
+
+// OnReconnect registers a handler called after each successful reconnect,
+// once the session has been re-established. Handlers may send commands.
+func (c *Client) OnReconnect(handler func()) {
+	c.reconnectMu.Lock()
+	c.reconnectHandlers = append(c.reconnectHandlers, handler)
+	c.reconnectMu.Unlock()
+}
+
+// currentConn returns the active transport.
+func (c *Client) currentConn() Transport {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.conn
+}
+
+// shouldReconnect reports whether a read error on conn should trigger a
+// reconnect rather than shutting the client down. Only WebSocket connections
+// can be redialed.
+func (c *Client) shouldReconnect(conn Transport) bool {
+	ws, ok := conn.(*Connection)
+	if !ok {
+		return false
+	}
+
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.reconnect != nil && !c.closing && !c.browserGone && !ws.isClosed()
+}
+
+// waitReconnect blocks while a reconnect is in progress.
+func (c *Client) waitReconnect(ctx context.Context) error {
+	c.connMu.Lock()
+	ch := c.reconnecting
+	c.connMu.Unlock()
+
+	if ch == nil {
+		return nil
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// failInFlight wakes commands awaiting a response without marking the client
+// as failed, so later commands can use the new connection.
+func (c *Client) failInFlight() {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+// reconnectLoop dials the URL of the lost connection with exponential
+// backoff. On success it swaps in the new connection, restores the session in
+// the background and returns true. Otherwise it fails all commands and
+// returns false.
+func (c *Client) reconnectLoop(lost Transport, cause error) bool {
+	// Mark the reconnect before failing in-flight commands, so a command
+	// sent in between waits for the new connection instead of being
+	// written to the lost one
+	done := make(chan struct{})
+	c.connMu.Lock()
+	c.reconnecting = done
+	opts := *c.reconnect
+	c.connMu.Unlock()
+
+	// Responses to in-flight commands were lost with the socket
+	c.failInFlight()
+
+	// Stop the lost connection's keepalive and release its socket
+	lost.Close()
+
+	delay := opts.BaseDelay
+	lastErr := cause
+	for attempt := 1; attempt <= opts.MaxAttempts; attempt++ {
+		if c.verbose {
+			fmt.Printf("       (connection lost, reconnect attempt %d in %s)\n", attempt, delay)
+		}
+		time.Sleep(delay)
+
+		c.connMu.Lock()
+		closing := c.closing
+		c.connMu.Unlock()
+		if closing {
+			lastErr = ErrClientClosed
+			break
+		}
+
+		ws := lost.(*Connection)
+		conn, err := ConnectWithOptions(ws.url, ws.opts)
+		if err == nil {
+			c.connMu.Lock()
+			if c.closing {
+				c.connMu.Unlock()
+				conn.Close()
+				lastErr = ErrClientClosed
+				break
+			}
+			c.conn = conn
+			c.connMu.Unlock()
+
+			// The read loop must keep running to receive the responses to
+			// the session restore, so finish the reconnect elsewhere
+			go c.finishReconnect(done)
+			return true
+		}
+		lastErr = err
+
+		delay *= 2
+		if delay > opts.MaxDelay {
+			delay = opts.MaxDelay
+		}
+	}
+
+	c.failPending(fmt.Errorf("reconnect failed after %d attempts: %w", opts.MaxAttempts, lastErr))
+	c.connMu.Lock()
+	c.reconnecting = nil
+	c.connMu.Unlock()
+	close(done)
+	return false
+}
+
+// finishReconnect restores the session on the new connection, releases
+// commands waiting for the reconnect and calls the OnReconnect handlers.
+func (c *Client) finishReconnect(done chan struct{}) {
+	newSession := c.restoreSession()
+
+	c.connMu.Lock()
+	resubscribe := !c.reconnect.NoResubscribe
+	c.connMu.Unlock()
+
+	if resubscribe {
+		c.restoreSubscriptions()
+		if newSession {
+			c.restorePreloadScripts()
+		}
+	}
+
+	c.connMu.Lock()
+	c.reconnecting = nil
+	c.connMu.Unlock()
+	close(done)
+
+	c.reconnectMu.Lock()
+	handlers := append([]func(){}, c.reconnectHandlers...)
+	c.reconnectMu.Unlock()
+
+	for _, handler := range handlers {
+		handler()
+	}
+}
+
+// restoreSession starts a new session if the client had created one with
+// NewSession, and reports whether it did.
+func (c *Client) restoreSession() bool {
+	c.connMu.Lock()
+	caps := c.sessionCaps
+	c.connMu.Unlock()
+
+	if caps == nil {
+		return false
+	}
+
+	params := map[string]interface{}{
+		"capabilities": *caps,
+	}
+	if _, err := c.send(context.Background(), "session.new", params); err != nil {
+		if c.verbose {
+			fmt.Printf("       (failed to restore session: %v)\n", err)
+		}
+		return false
+	}
+	return true
+}
+
+// restoreSubscriptions replays the recorded subscriptions and updates their
+// ids to the ones issued by the new connection.
+func (c *Client) restoreSubscriptions() {
+	c.subscriptionsMu.Lock()
+	subs := append([]Subscription(nil), c.subscriptions...)
+	c.subscriptionsMu.Unlock()
+
+	ids := make(map[string]string, len(subs))
+	for _, sub := range subs {
+		params := map[string]interface{}{
+			"events": sub.Events,
+		}
+		if len(sub.Contexts) > 0 {
+			params["contexts"] = sub.Contexts
+		}
+
+		msg, err := c.send(context.Background(), "session.subscribe", params)
+		if err != nil {
+			if c.verbose {
+				fmt.Printf("       (failed to restore subscription to %v: %v)\n", sub.Events, err)
+			}
+			continue
+		}
+
+		var result SubscribeResult
+		if len(msg.Result) > 0 && json.Unmarshal(msg.Result, &result) == nil && sub.ID != "" {
+			ids[sub.ID] = result.Subscription
+		}
+	}
+
+	c.subscriptionsMu.Lock()
+	for i, sub := range c.subscriptions {
+		if id, ok := ids[sub.ID]; ok {
+			c.subscriptions[i].ID = id
+		}
+	}
+	c.subscriptionsMu.Unlock()
+}
+
+// restorePreloadScripts re-adds preload scripts to a new session.
+func (c *Client) restorePreloadScripts() {
+	c.preloadMu.Lock()
+	scripts := make([]*preloadScript, 0, len(c.preloads))
+	for _, script := range c.preloads {
+		scripts = append(scripts, script)
+	}
+	c.preloadMu.Unlock()
+
+	for _, script := range scripts {
+		msg, err := c.send(context.Background(), "script.addPreloadScript", script.params)
+		if err != nil {
+			if c.verbose {
+				fmt.Printf("       (failed to restore preload script: %v)\n", err)
+			}
+			continue
+		}
+
+		var result AddPreloadScriptResult
+		if err := json.Unmarshal(msg.Result, &result); err != nil {
+			continue
+		}
+
+		c.preloadMu.Lock()
+		script.id = result.Script
+		c.preloadMu.Unlock()
+	}
+}
@@ -0,0 +1,61 @@
+package bidi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestReconnectClosesLostConnection(t *testing.T) {
+	// The first connection drops on its first command; later ones answer
+	var conns int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		first := atomic.AddInt32(&conns, 1) == 1
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil || first {
+				return
+			}
+			var cmd fakeCommand
+			if err := json.Unmarshal(data, &cmd); err != nil {
+				return
+			}
+			reply := fmt.Sprintf(`{"id":%d,"type":"success","result":{}}`, cmd.ID)
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(reply)); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	lost, err := Connect("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	client := NewClient(lost)
+	client.EnableReconnect(ReconnectOpts{BaseDelay: time.Millisecond})
+	defer client.Close()
+
+	if _, err := client.SendCommand("test.first", nil); err == nil {
+		t.Fatal("command on the dropped connection succeeded")
+	}
+	if _, err := client.SendCommand("test.second", nil); err != nil {
+		t.Fatalf("command after reconnect: %v", err)
+	}
+	if !lost.isClosed() {
+		t.Error("lost connection was not closed after reconnecting")
+	}
+}
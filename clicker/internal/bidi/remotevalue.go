@@ -0,0 +1,300 @@
+package bidi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// RemoteValue represents a value returned from script evaluation.
+type RemoteValue struct {
+	Type     string      `json:"type"`
+	Value    interface{} `json:"value,omitempty"`
+	Handle   string      `json:"handle,omitempty"`
+	SharedID string      `json:"sharedId,omitempty"`
+}
+
+// RemoteReference refers to an object that already lives in the browser,
+// either by handle (from "root" result ownership) or by shared node id.
+type RemoteReference struct {
+	Handle   string `json:"handle,omitempty"`
+	SharedID string `json:"sharedId,omitempty"`
+}
+
+// Reference returns a reference to the remote value, or nil if it has
+// neither a handle nor a shared id.
+func (v *RemoteValue) Reference() *RemoteReference {
+	if v == nil || (v.Handle == "" && v.SharedID == "") {
+		return nil
+	}
+	return &RemoteReference{Handle: v.Handle, SharedID: v.SharedID}
+}
+
+// serialize returns the BiDi argument form of the reference.
+func (r *RemoteReference) serialize() map[string]interface{} {
+	ref := map[string]interface{}{}
+	if r.Handle != "" {
+		ref["handle"] = r.Handle
+	}
+	if r.SharedID != "" {
+		ref["sharedId"] = r.SharedID
+	}
+	return ref
+}
+
+// RegExpValue represents a decoded BiDi regexp remote value.
+type RegExpValue struct {
+	Pattern string `json:"pattern"`
+	Flags   string `json:"flags,omitempty"`
+}
+
+// Node is a decoded DOM node remote value. Children and ShadowRoot are only
+// populated to the serialization depth the browser used.
+type Node struct {
+	SharedID       string
+	Handle         string
+	NodeType       int // DOM nodeType, e.g. 1 for elements and 3 for text
+	NodeValue      string
+	LocalName      string
+	NamespaceURI   string
+	ChildNodeCount int
+	Attributes     map[string]string
+	Children       []*Node
+	ShadowRoot     *Node
+	Mode           string // "open" or "closed" for shadow roots
+}
+
+// RemoteValue returns a node remote value referring to n, for methods that
+// take a *RemoteValue.
+func (n *Node) RemoteValue() *RemoteValue {
+	return &RemoteValue{Type: "node", SharedID: n.SharedID, Handle: n.Handle}
+}
+
+// Reference returns a reference to the node, or nil if it has neither a
+// handle nor a shared id.
+func (n *Node) Reference() *RemoteReference {
+	if n == nil || (n.Handle == "" && n.SharedID == "") {
+		return nil
+	}
+	return &RemoteReference{Handle: n.Handle, SharedID: n.SharedID}
+}
+
+// rawNodeProperties is the wire shape of a node remote value's value.
+type rawNodeProperties struct {
+	NodeType       int               `json:"nodeType"`
+	NodeValue      string            `json:"nodeValue,omitempty"`
+	LocalName      string            `json:"localName,omitempty"`
+	NamespaceURI   string            `json:"namespaceURI,omitempty"`
+	ChildNodeCount int               `json:"childNodeCount"`
+	Attributes     map[string]string `json:"attributes,omitempty"`
+	Children       []json.RawMessage `json:"children,omitempty"`
+	ShadowRoot     json.RawMessage   `json:"shadowRoot,omitempty"`
+	Mode           string            `json:"mode,omitempty"`
+}
+
+// decodeNode decodes a node remote value, including its children.
+func decodeNode(raw rawRemoteValue) (*Node, error) {
+	node := &Node{SharedID: raw.SharedID, Handle: raw.Handle}
+	if len(raw.Value) == 0 {
+		return node, nil
+	}
+
+	var props rawNodeProperties
+	if err := json.Unmarshal(raw.Value, &props); err != nil {
+		return nil, fmt.Errorf("failed to parse node value: %w", err)
+	}
+
+	node.NodeType = props.NodeType
+	node.NodeValue = props.NodeValue
+	node.LocalName = props.LocalName
+	node.NamespaceURI = props.NamespaceURI
+	node.ChildNodeCount = props.ChildNodeCount
+	node.Attributes = props.Attributes
+	node.Mode = props.Mode
+
+	for _, child := range props.Children {
+		var rawChild rawRemoteValue
+		if err := json.Unmarshal(child, &rawChild); err != nil {
+			return nil, fmt.Errorf("failed to parse child node: %w", err)
+		}
+		c, err := decodeNode(rawChild)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, c)
+	}
+
+	if len(props.ShadowRoot) > 0 && string(props.ShadowRoot) != "null" {
+		var rawRoot rawRemoteValue
+		if err := json.Unmarshal(props.ShadowRoot, &rawRoot); err != nil {
+			return nil, fmt.Errorf("failed to parse shadow root: %w", err)
+		}
+		root, err := decodeNode(rawRoot)
+		if err != nil {
+			return nil, err
+		}
+		node.ShadowRoot = root
+	}
+
+	return node, nil
+}
+
+// rawRemoteValue is the wire shape of a remote value before decoding.
+type rawRemoteValue struct {
+	Type     string          `json:"type"`
+	Value    json.RawMessage `json:"value,omitempty"`
+	Handle   string          `json:"handle,omitempty"`
+	SharedID string          `json:"sharedId,omitempty"`
+}
+
+// decodeRemoteValue converts a BiDi remote value into a native Go value.
+// Primitives map to nil, bool, float64, string and *big.Int; dates map to
+// time.Time in UTC; arrays and sets map to []interface{}; objects and maps
+// map to map[string]interface{}; nodes map to *Node.
+// Other non-serializable values are returned as *RemoteValue.
+func decodeRemoteValue(data json.RawMessage) (interface{}, error) {
+	var raw rawRemoteValue
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse remote value: %w", err)
+	}
+
+	switch raw.Type {
+	case "undefined", "null":
+		return nil, nil
+	case "string":
+		var s string
+		if err := json.Unmarshal(raw.Value, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse string value: %w", err)
+		}
+		return s, nil
+	case "boolean":
+		var b bool
+		if err := json.Unmarshal(raw.Value, &b); err != nil {
+			return nil, fmt.Errorf("failed to parse boolean value: %w", err)
+		}
+		return b, nil
+	case "number":
+		return decodeNumber(raw.Value)
+	case "bigint":
+		var s string
+		if err := json.Unmarshal(raw.Value, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse bigint value: %w", err)
+		}
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid bigint value: %s", s)
+		}
+		return n, nil
+	case "array", "set":
+		return decodeList(raw.Value)
+	case "object", "map":
+		return decodeMapping(raw.Value)
+	case "date":
+		var s string
+		if err := json.Unmarshal(raw.Value, &s); err != nil {
+			return nil, fmt.Errorf("failed to parse date value: %w", err)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date value: %w", err)
+		}
+		return t.UTC(), nil
+	case "regexp":
+		var re RegExpValue
+		if err := json.Unmarshal(raw.Value, &re); err != nil {
+			return nil, fmt.Errorf("failed to parse regexp value: %w", err)
+		}
+		return re, nil
+	case "node":
+		return decodeNode(raw)
+	default:
+		// Windows, functions, promises, etc. cannot be represented
+		// natively, so keep them as typed remote values.
+		rv := &RemoteValue{Type: raw.Type, Handle: raw.Handle, SharedID: raw.SharedID}
+		if len(raw.Value) > 0 {
+			if err := json.Unmarshal(raw.Value, &rv.Value); err != nil {
+				return nil, fmt.Errorf("failed to parse %s value: %w", raw.Type, err)
+			}
+		}
+		return rv, nil
+	}
+}
+
+// decodeNumber decodes a BiDi number, including the special values that
+// cannot be represented in JSON.
+func decodeNumber(data json.RawMessage) (float64, error) {
+	var special string
+	if err := json.Unmarshal(data, &special); err == nil {
+		switch special {
+		case "NaN":
+			return math.NaN(), nil
+		case "-0":
+			return math.Copysign(0, -1), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		default:
+			return 0, fmt.Errorf("invalid number value: %s", special)
+		}
+	}
+
+	var f float64
+	if err := json.Unmarshal(data, &f); err != nil {
+		return 0, fmt.Errorf("failed to parse number value: %w", err)
+	}
+	return f, nil
+}
+
+// decodeList decodes the value of an array or set remote value.
+func decodeList(data json.RawMessage) ([]interface{}, error) {
+	var items []json.RawMessage
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("failed to parse list value: %w", err)
+		}
+	}
+
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		v, err := decodeRemoteValue(item)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = v
+	}
+	return result, nil
+}
+
+// decodeMapping decodes the value of an object or map remote value.
+// Entries are [key, value] pairs where the key is either a plain string
+// or a remote value; non-string keys are formatted with fmt.Sprint.
+func decodeMapping(data json.RawMessage) (map[string]interface{}, error) {
+	var entries [][2]json.RawMessage
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse mapping value: %w", err)
+		}
+	}
+
+	result := make(map[string]interface{}, len(entries))
+	for _, entry := range entries {
+		var key string
+		if err := json.Unmarshal(entry[0], &key); err != nil {
+			k, err := decodeRemoteValue(entry[0])
+			if err != nil {
+				return nil, err
+			}
+			key = fmt.Sprint(k)
+		}
+
+		v, err := decodeRemoteValue(entry[1])
+		if err != nil {
+			return nil, err
+		}
+		result[key] = v
+	}
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package bidi
+
+import (
+	"errors"
+	"time"
+
+	errs "github.com/vibium/clicker/internal/errors"
+)
+
+// DefaultRetryableCodes are the BiDi error codes WithRetry treats as transient.
+// "no such frame" is returned while a context is being swapped during
+// navigation.
+var DefaultRetryableCodes = []string{"no such frame"}
+
+// RetryPolicy retries a function while it fails with a retryable BiDi error.
+type RetryPolicy struct {
+	Attempts int           // total attempts, including the first
+	Backoff  time.Duration // delay before the first retry, doubled after each
+	Codes    []string      // retryable error codes; nil uses DefaultRetryableCodes
+}
+
+// WithRetry calls fn up to attempts times, waiting backoff before the first
+// retry and doubling it after each, while fn fails with one of the
+// DefaultRetryableCodes. Any other error is returned immediately.
+func WithRetry(attempts int, backoff time.Duration, fn func() error) error {
+	return RetryPolicy{Attempts: attempts, Backoff: backoff}.Do(fn)
+}
+
+// Do calls fn according to the policy and returns its last error.
+func (p RetryPolicy) Do(fn func() error) error {
+	codes := p.Codes
+	if codes == nil {
+		codes = DefaultRetryableCodes
+	}
+
+	delay := p.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || attempt >= p.Attempts || !isRetryable(err, codes) {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// isRetryable reports whether err is a BiDi error with one of the given codes.
+func isRetryable(err error, codes []string) bool {
+	var bidiErr *errs.BiDiError
+	if !errors.As(err, &bidiErr) {
+		return false
+	}
+	for _, code := range codes {
+		if bidiErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,13 @@
 package bidi
 
 import (
+	stdcontext "context"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 )
 
 // RealmInfo represents information about a JavaScript realm.
@@ -38,21 +43,185 @@ func (c *Client) GetRealms(context string) (*GetRealmsResult, error) {
 	return &result, nil
 }
 
-// EvaluateResult represents the result of script.evaluate.
+// EvaluateResult represents the result of script.evaluate or script.callFunction.
 type EvaluateResult struct {
-	Type   string          `json:"type"`
-	Result json.RawMessage `json:"result"`
+	Type             string            `json:"type"`
+	Result           json.RawMessage   `json:"result"`
+	ExceptionDetails *ExceptionDetails `json:"exceptionDetails,omitempty"`
+	Realm            string            `json:"realm,omitempty"`
 }
 
-// RemoteValue represents a value returned from script evaluation.
-type RemoteValue struct {
-	Type  string      `json:"type"`
-	Value interface{} `json:"value,omitempty"`
+// StackFrame represents a single frame in a script stack trace.
+type StackFrame struct {
+	ColumnNumber int    `json:"columnNumber"`
+	FunctionName string `json:"functionName"`
+	LineNumber   int    `json:"lineNumber"`
+	URL          string `json:"url"`
+}
+
+// StackTrace represents a script stack trace.
+type StackTrace struct {
+	CallFrames []StackFrame `json:"callFrames"`
+}
+
+// ExceptionDetails describes an exception thrown during script evaluation.
+type ExceptionDetails struct {
+	ColumnNumber int         `json:"columnNumber"`
+	Exception    RemoteValue `json:"exception"`
+	LineNumber   int         `json:"lineNumber"`
+	StackTrace   StackTrace  `json:"stackTrace"`
+	Text         string      `json:"text"`
+}
+
+// ScriptException is returned when a script throws during evaluation.
+type ScriptException struct {
+	Details ExceptionDetails
+}
+
+func (e *ScriptException) Error() string {
+	return fmt.Sprintf("script exception: %s (line %d, column %d)",
+		e.Details.Text, e.Details.LineNumber, e.Details.ColumnNumber)
+}
+
+// parseEvaluateResult parses a script.evaluate or script.callFunction result.
+// A thrown exception is returned as a *ScriptException.
+func parseEvaluateResult(method string, data json.RawMessage) (*EvaluateResult, error) {
+	var result EvaluateResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse %s result: %w", method, err)
+	}
+
+	if result.Type == "exception" {
+		exc := &ScriptException{}
+		if result.ExceptionDetails != nil {
+			exc.Details = *result.ExceptionDetails
+		}
+		return nil, exc
+	}
+
+	return &result, nil
 }
 
 // Evaluate evaluates a JavaScript expression and returns the result.
 // If context is empty, it uses the first available context.
 func (c *Client) Evaluate(context, expression string) (interface{}, error) {
+	return c.EvaluateContext(stdcontext.Background(), context, expression)
+}
+
+// EvaluateContext is like Evaluate but gives up when ctx is done.
+func (c *Client) EvaluateContext(ctx stdcontext.Context, context, expression string) (interface{}, error) {
+	evalResult, err := c.evaluate(ctx, context, expression, scriptOptions{ownership: "none", awaitPromise: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRemoteValue(evalResult.Result)
+}
+
+// EvaluateInSandbox evaluates a JavaScript expression in a named sandbox realm,
+// isolated from page scripts. An empty sandbox evaluates in the main realm.
+// If context is empty, it uses the first available context.
+func (c *Client) EvaluateInSandbox(context, sandbox, expression string) (interface{}, error) {
+	evalResult, err := c.evaluate(stdcontext.Background(), context, expression, scriptOptions{sandbox: sandbox, ownership: "none", awaitPromise: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRemoteValue(evalResult.Result)
+}
+
+// EvaluateInto evaluates an expression and stores the result in out, which
+// must be a pointer, using the same rules as json.Unmarshal. It returns a
+// *ScriptException if the expression throws.
+func (c *Client) EvaluateInto(context, expression string, out interface{}) error {
+	value, err := c.Evaluate(context, expression)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode evaluation result: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode evaluation result: %w", err)
+	}
+	return nil
+}
+
+// EvaluateWithOwnership evaluates a JavaScript expression and returns the raw
+// remote value. With ownership "root" the value carries a handle that keeps the
+// object alive in the realm so it can be passed back in later calls.
+// If context is empty, it uses the first available context.
+func (c *Client) EvaluateWithOwnership(context, expression, ownership string) (*RemoteValue, error) {
+	return c.EvaluateWithOpts(context, expression, EvaluateOpts{ResultOwnership: ownership})
+}
+
+// EvaluateOpts configures EvaluateWithOpts.
+type EvaluateOpts struct {
+	// AwaitPromise controls whether a returned promise is awaited.
+	// Nil means true; when false the promise itself is returned.
+	AwaitPromise *bool
+
+	// ResultOwnership is "none" (default) or "root".
+	ResultOwnership string
+
+	// Sandbox names an isolated realm. Empty means the main realm.
+	Sandbox string
+}
+
+// EvaluateWithOpts evaluates a JavaScript expression with the given options
+// and returns the raw remote value.
+// If context is empty, it uses the first available context.
+func (c *Client) EvaluateWithOpts(context, expression string, opts EvaluateOpts) (*RemoteValue, error) {
+	ownership := opts.ResultOwnership
+	if ownership == "" {
+		ownership = "none"
+	}
+	if ownership != "none" && ownership != "root" {
+		return nil, fmt.Errorf("invalid result ownership: %s (expected \"root\" or \"none\")", ownership)
+	}
+
+	awaitPromise := true
+	if opts.AwaitPromise != nil {
+		awaitPromise = *opts.AwaitPromise
+	}
+
+	evalResult, err := c.evaluate(stdcontext.Background(), context, expression, scriptOptions{
+		sandbox:      opts.Sandbox,
+		ownership:    ownership,
+		awaitPromise: awaitPromise,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var remoteValue RemoteValue
+	if err := json.Unmarshal(evalResult.Result, &remoteValue); err != nil {
+		return nil, fmt.Errorf("failed to parse remote value: %w", err)
+	}
+
+	return &remoteValue, nil
+}
+
+// scriptOptions holds settings shared by script.evaluate and script.callFunction.
+type scriptOptions struct {
+	sandbox      string
+	ownership    string
+	awaitPromise bool
+}
+
+// scriptTarget builds a script target for a context and optional sandbox.
+func scriptTarget(context, sandbox string) map[string]interface{} {
+	target := map[string]interface{}{"context": context}
+	if sandbox != "" {
+		target["sandbox"] = sandbox
+	}
+	return target
+}
+
+// evaluate sends script.evaluate with the given options.
+func (c *Client) evaluate(ctx stdcontext.Context, context, expression string, opts scriptOptions) (*EvaluateResult, error) {
 	// If no context provided, get the first one from the tree
 	if context == "" {
 		tree, err := c.GetTree()
@@ -66,42 +235,87 @@ func (c *Client) Evaluate(context, expression string) (interface{}, error) {
 	}
 
 	params := map[string]interface{}{
-		"expression":    expression,
-		"target":        map[string]interface{}{"context": context},
-		"awaitPromise":  true,
-		"resultOwnership": "none",
+		"expression":      expression,
+		"target":          scriptTarget(context, opts.sandbox),
+		"awaitPromise":    opts.awaitPromise,
+		"resultOwnership": opts.ownership,
 	}
 
-	msg, err := c.SendCommand("script.evaluate", params)
+	msg, err := c.SendCommandContext(ctx, "script.evaluate", params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse the result
-	var evalResult struct {
-		Type   string          `json:"type"`
-		Result json.RawMessage `json:"result"`
-	}
-	if err := json.Unmarshal(msg.Result, &evalResult); err != nil {
-		return nil, fmt.Errorf("failed to parse script.evaluate result: %w", err)
+	return parseEvaluateResult("script.evaluate", msg.Result)
+}
+
+// Disown releases handles obtained with "root" result ownership so the
+// browser can garbage-collect the referenced objects.
+// If context is empty, it uses the first available context.
+func (c *Client) Disown(context string, handles []string) error {
+	if len(handles) == 0 {
+		return fmt.Errorf("no handles to disown")
 	}
 
-	if evalResult.Type == "exception" {
-		return nil, fmt.Errorf("script exception: %s", string(evalResult.Result))
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
 	}
 
-	// Parse the remote value
-	var remoteValue RemoteValue
-	if err := json.Unmarshal(evalResult.Result, &remoteValue); err != nil {
-		return nil, fmt.Errorf("failed to parse remote value: %w", err)
+	params := map[string]interface{}{
+		"handles": handles,
+		"target":  map[string]interface{}{"context": context},
 	}
 
-	return remoteValue.Value, nil
+	_, err := c.SendCommand("script.disown", params)
+	return err
+}
+
+// DisownValue releases the handle held by a remote value.
+func (c *Client) DisownValue(context string, value *RemoteValue) error {
+	if value == nil || value.Handle == "" {
+		return fmt.Errorf("remote value has no handle to disown")
+	}
+	return c.Disown(context, []string{value.Handle})
 }
 
 // CallFunction calls a JavaScript function with arguments.
 // If context is empty, it uses the first available context.
 func (c *Client) CallFunction(context, functionDeclaration string, args []interface{}) (interface{}, error) {
+	return c.CallFunctionContext(stdcontext.Background(), context, functionDeclaration, args)
+}
+
+// CallFunctionContext is like CallFunction but gives up when ctx is done.
+func (c *Client) CallFunctionContext(ctx stdcontext.Context, context, functionDeclaration string, args []interface{}) (interface{}, error) {
+	callResult, err := c.callFunction(ctx, context, functionDeclaration, args, scriptOptions{ownership: "none", awaitPromise: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRemoteValue(callResult.Result)
+}
+
+// CallFunctionInSandbox calls a JavaScript function in a named sandbox realm.
+// An empty sandbox calls the function in the main realm.
+// If context is empty, it uses the first available context.
+func (c *Client) CallFunctionInSandbox(context, sandbox, functionDeclaration string, args []interface{}) (interface{}, error) {
+	callResult, err := c.callFunction(stdcontext.Background(), context, functionDeclaration, args, scriptOptions{sandbox: sandbox, ownership: "none", awaitPromise: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRemoteValue(callResult.Result)
+}
+
+// callFunction sends script.callFunction with the given options.
+func (c *Client) callFunction(ctx stdcontext.Context, context, functionDeclaration string, args []interface{}, opts scriptOptions) (*EvaluateResult, error) {
 	// If no context provided, get the first one from the tree
 	if context == "" {
 		tree, err := c.GetTree()
@@ -122,52 +336,334 @@ func (c *Client) CallFunction(context, functionDeclaration string, args []interf
 
 	params := map[string]interface{}{
 		"functionDeclaration": functionDeclaration,
-		"target":              map[string]interface{}{"context": context},
+		"target":              scriptTarget(context, opts.sandbox),
 		"arguments":           serializedArgs,
-		"awaitPromise":        true,
-		"resultOwnership":     "none",
+		"awaitPromise":        opts.awaitPromise,
+		"resultOwnership":     opts.ownership,
 	}
 
-	msg, err := c.SendCommand("script.callFunction", params)
+	msg, err := c.SendCommandContext(ctx, "script.callFunction", params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse the result
-	var callResult struct {
-		Type   string          `json:"type"`
-		Result json.RawMessage `json:"result"`
+	return parseEvaluateResult("script.callFunction", msg.Result)
+}
+
+// ChannelValue is a preload script or function argument that page code can
+// call to send messages back to the client as script.message events.
+type ChannelValue struct {
+	// Channel is a client-chosen id that identifies the channel in events.
+	Channel string
+
+	// Ownership is the result ownership of values sent over the channel.
+	Ownership string
+}
+
+// serialize returns the BiDi channel argument form.
+func (ch ChannelValue) serialize() map[string]interface{} {
+	value := map[string]interface{}{"channel": ch.Channel}
+	if ch.Ownership != "" {
+		value["ownership"] = ch.Ownership
+	}
+	return map[string]interface{}{"type": "channel", "value": value}
+}
+
+// MessageSource identifies the realm that sent a script message.
+type MessageSource struct {
+	Realm   string `json:"realm"`
+	Context string `json:"context,omitempty"`
+}
+
+// ScriptMessageEvent is a message sent by page code over a channel.
+type ScriptMessageEvent struct {
+	Channel string
+	Data    interface{}
+	Source  MessageSource
+}
+
+// OnScriptMessage subscribes to script.message events, which are sent when
+// page code calls a function passed in as a ChannelValue.
+func (c *Client) OnScriptMessage(handler func(ScriptMessageEvent)) error {
+	return c.subscribeHandler("script.message", nil, func(params json.RawMessage) {
+		var event struct {
+			Channel string          `json:"channel"`
+			Data    json.RawMessage `json:"data"`
+			Source  MessageSource   `json:"source"`
+		}
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+
+		data, err := decodeRemoteValue(event.Data)
+		if err != nil {
+			return
+		}
+
+		handler(ScriptMessageEvent{
+			Channel: event.Channel,
+			Data:    data,
+			Source:  event.Source,
+		})
+	})
+}
+
+// PreloadScriptOpts configures AddPreloadScript.
+type PreloadScriptOpts struct {
+	// Arguments are channels passed to the preload function.
+	Arguments []ChannelValue
+
+	// Contexts limits the script to these top-level contexts. Empty means all.
+	Contexts []string
+
+	// Sandbox runs the script in a named isolated realm.
+	Sandbox string
+}
+
+// AddPreloadScriptResult represents the result of script.addPreloadScript.
+type AddPreloadScriptResult struct {
+	Script string `json:"script"`
+}
+
+// AddPreloadScript registers a function that runs on every new document
+// before any page script, and returns the preload script id.
+func (c *Client) AddPreloadScript(functionDeclaration string, opts PreloadScriptOpts) (string, error) {
+	params := map[string]interface{}{
+		"functionDeclaration": functionDeclaration,
 	}
-	if err := json.Unmarshal(msg.Result, &callResult); err != nil {
-		return nil, fmt.Errorf("failed to parse script.callFunction result: %w", err)
+	if len(opts.Arguments) > 0 {
+		args := make([]map[string]interface{}, len(opts.Arguments))
+		for i, arg := range opts.Arguments {
+			args[i] = arg.serialize()
+		}
+		params["arguments"] = args
+	}
+	if len(opts.Contexts) > 0 {
+		params["contexts"] = opts.Contexts
+	}
+	if opts.Sandbox != "" {
+		params["sandbox"] = opts.Sandbox
 	}
 
-	if callResult.Type == "exception" {
-		return nil, fmt.Errorf("script exception: %s", string(callResult.Result))
+	msg, err := c.SendCommand("script.addPreloadScript", params)
+	if err != nil {
+		return "", err
 	}
 
-	// Parse the remote value
-	var remoteValue RemoteValue
-	if err := json.Unmarshal(callResult.Result, &remoteValue); err != nil {
-		return nil, fmt.Errorf("failed to parse remote value: %w", err)
+	var result AddPreloadScriptResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return "", fmt.Errorf("failed to parse script.addPreloadScript result: %w", err)
 	}
 
-	return remoteValue.Value, nil
+	// Keep the params so the script can be re-added to a new session after
+	// a reconnect; the original id stays valid for RemovePreloadScript
+	c.preloadMu.Lock()
+	c.preloads[result.Script] = &preloadScript{id: result.Script, params: params}
+	c.preloadMu.Unlock()
+
+	return result.Script, nil
+}
+
+// preloadScript records an added preload script.
+type preloadScript struct {
+	id     string // id in the current session
+	params map[string]interface{}
 }
 
-// serializeValue converts a Go value to a BiDi serialized value.
+// RemovePreloadScript removes a preload script previously added with AddPreloadScript.
+func (c *Client) RemovePreloadScript(scriptID string) error {
+	if scriptID == "" {
+		return fmt.Errorf("preload script id is required")
+	}
+
+	c.preloadMu.Lock()
+	current := scriptID
+	if script, ok := c.preloads[scriptID]; ok {
+		current = script.id
+	}
+	c.preloadMu.Unlock()
+
+	params := map[string]interface{}{
+		"script": current,
+	}
+
+	if _, err := c.SendCommand("script.removePreloadScript", params); err != nil {
+		return fmt.Errorf("failed to remove preload script %s: %w", scriptID, err)
+	}
+
+	c.preloadMu.Lock()
+	delete(c.preloads, scriptID)
+	c.preloadMu.Unlock()
+	return nil
+}
+
+// NullValue is the type of Null.
+type NullValue struct{}
+
+// Null is passed as a script argument to send JavaScript null; a plain nil
+// is sent as undefined.
+var Null = NullValue{}
+
+// serializeValue converts a Go value to a BiDi serialized value. Structs
+// become objects keyed by their json tags; nil maps to undefined, and Null
+// and nil pointers to null.
 func serializeValue(v interface{}) map[string]interface{} {
 	switch val := v.(type) {
 	case nil:
 		return map[string]interface{}{"type": "undefined"}
+	case NullValue, *NullValue:
+		return map[string]interface{}{"type": "null"}
 	case bool:
 		return map[string]interface{}{"type": "boolean", "value": val}
 	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
 		return map[string]interface{}{"type": "number", "value": val}
 	case string:
 		return map[string]interface{}{"type": "string", "value": val}
+	case ChannelValue:
+		return val.serialize()
+	case RemoteReference:
+		return val.serialize()
+	case *RemoteReference:
+		return val.serialize()
+	case *Node:
+		if val == nil {
+			return map[string]interface{}{"type": "undefined"}
+		}
+		if ref := val.Reference(); ref != nil {
+			return ref.serialize()
+		}
+		return map[string]interface{}{"type": "undefined"}
+	case *RemoteValue:
+		if val == nil {
+			return map[string]interface{}{"type": "undefined"}
+		}
+		// Pass objects that live in the browser back by reference
+		if ref := val.Reference(); ref != nil {
+			return ref.serialize()
+		}
+		return map[string]interface{}{"type": val.Type, "value": val.Value}
+	case time.Time:
+		return map[string]interface{}{"type": "date", "value": val.UTC().Format(time.RFC3339Nano)}
+	case []interface{}:
+		items := make([]map[string]interface{}, len(val))
+		for i, item := range val {
+			items[i] = serializeValue(item)
+		}
+		return map[string]interface{}{"type": "array", "value": items}
+	}
+
+	// Named types such as time.Duration or a string enum do not match the
+	// cases above, so serialize them by their underlying kind
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean", "value": rv.Bool()}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "number", "value": rv.Int()}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "number", "value": rv.Uint()}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number", "value": rv.Float()}
+	case reflect.String:
+		return map[string]interface{}{"type": "string", "value": rv.String()}
+	case reflect.Slice, reflect.Array:
+		items := make([]map[string]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			items[i] = serializeValue(rv.Index(i).Interface())
+		}
+		return map[string]interface{}{"type": "array", "value": items}
+	case reflect.Map:
+		// Object keys must be strings; sort them so the output is deterministic
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := fmt.Sprintf("%v", iter.Key().Interface())
+			keys = append(keys, key)
+			values[key] = iter.Value().Interface()
+		}
+		sort.Strings(keys)
+
+		entries := make([][]interface{}, len(keys))
+		for i, key := range keys {
+			entries[i] = []interface{}{key, serializeValue(values[key])}
+		}
+		return map[string]interface{}{"type": "object", "value": entries}
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return map[string]interface{}{"type": "null"}
+		}
+		return serializeValue(rv.Elem().Interface())
+	case reflect.Struct:
+		entries := [][]interface{}{}
+		appendStructFields(&entries, rv)
+		return map[string]interface{}{"type": "object", "value": entries}
 	default:
-		// For complex types, try to serialize as string
-		return map[string]interface{}{"type": "string", "value": fmt.Sprintf("%v", val)}
+		// For other complex types, try to serialize as string
+		return map[string]interface{}{"type": "string", "value": fmt.Sprintf("%v", v)}
+	}
+}
+
+// appendStructFields appends a struct's exported fields as object entries,
+// named and filtered by their json tags the way encoding/json does.
+// Untagged embedded structs are flattened into the parent object.
+func appendStructFields(entries *[][]interface{}, rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		value := rv.Field(i)
+
+		// Promote the exported fields of untagged embedded structs, even when
+		// the embedded type itself is unexported. Like encoding/json, skip
+		// embedded pointers to unexported struct types.
+		if field.Anonymous && name == "" {
+			if value.Kind() == reflect.Ptr {
+				if !field.IsExported() || value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				appendStructFields(entries, value)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmptyValue(value) {
+			continue
+		}
+
+		*entries = append(*entries, []interface{}{name, serializeValue(value.Interface())})
+	}
+}
+
+// isEmptyValue reports whether a value is empty for the json omitempty option.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
 	}
+	return false
 }
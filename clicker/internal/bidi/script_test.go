@@ -0,0 +1,117 @@
+package bidi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// mustJSON encodes v for comparison in tests.
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	return string(data)
+}
+
+type testMode int
+
+type testEmbedded struct {
+	Shared string `json:"shared"`
+}
+
+type TestExported struct {
+	Level int
+}
+
+type testConfig struct {
+	testEmbedded
+	*TestExported
+	Mode    testMode      `json:"mode"`
+	Delay   time.Duration `json:"delay"`
+	Enabled bool          `json:"enabled,omitempty"`
+	Name    string        `json:"name"`
+	Skip    string        `json:"-"`
+	Next    *testConfig   `json:"next"`
+	private int
+}
+
+func TestSerializeValueNamedKinds(t *testing.T) {
+	type flag bool
+	type label string
+
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"named int", testMode(3), `{"type":"number","value":3}`},
+		{"duration", time.Duration(5), `{"type":"number","value":5}`},
+		{"named bool", flag(true), `{"type":"boolean","value":true}`},
+		{"named string", label("x"), `{"type":"string","value":"x"}`},
+		{"json number", json.Number("1.5"), `{"type":"string","value":"1.5"}`},
+		{"named uint", uint16(7), `{"type":"number","value":7}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustJSON(t, serializeValue(tt.in)); got != tt.want {
+				t.Errorf("serializeValue(%v) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSerializeValueStruct(t *testing.T) {
+	in := testConfig{
+		testEmbedded: testEmbedded{Shared: "s"},
+		TestExported: &TestExported{Level: 2},
+		Mode:         testMode(1),
+		Delay:        time.Millisecond,
+		Name:         "n",
+		Skip:         "skipped",
+	}
+
+	want := `{"type":"object","value":[` +
+		`["shared",{"type":"string","value":"s"}],` +
+		`["Level",{"type":"number","value":2}],` +
+		`["mode",{"type":"number","value":1}],` +
+		`["delay",{"type":"number","value":1000000}],` +
+		`["name",{"type":"string","value":"n"}],` +
+		`["next",{"type":"null"}]]}`
+	if got := mustJSON(t, serializeValue(in)); got != want {
+		t.Errorf("serializeValue(struct) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSerializeValueNested(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{
+			"slice of maps",
+			[]map[string]interface{}{{"b": 1, "a": "x"}, {"c": nil}},
+			`{"type":"array","value":[` +
+				`{"type":"object","value":[["a",{"type":"string","value":"x"}],["b",{"type":"number","value":1}]]},` +
+				`{"type":"object","value":[["c",{"type":"undefined"}]]}]}`,
+		},
+		{
+			"map of int slices",
+			map[string][]int{"z": {3}, "a": {1, 2}, "m": {}},
+			`{"type":"object","value":[` +
+				`["a",{"type":"array","value":[{"type":"number","value":1},{"type":"number","value":2}]}],` +
+				`["m",{"type":"array","value":[]}],` +
+				`["z",{"type":"array","value":[{"type":"number","value":3}]}]]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustJSON(t, serializeValue(tt.in)); got != tt.want {
+				t.Errorf("serializeValue(%v) =\n%s\nwant\n%s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,19 +1,80 @@
 package bidi
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	errs "github.com/vibium/clicker/internal/errors"
 )
 
-// Client is a BiDi client that wraps a WebSocket connection.
+// Client is a BiDi client that wraps a transport, usually a WebSocket Connection.
+//
+// The client owns reading from the connection once its first command is sent
+// or its first event handler is registered. After that, responses are matched
+// to commands by id and events are passed to handlers registered with On.
 type Client struct {
-	conn    *Connection
-	verbose bool
+	// DefaultTimeout bounds how long SendCommand waits for a response.
+	// Zero means wait forever. It does not apply to SendCommandContext.
+	// Set it before sending commands.
+	DefaultTimeout time.Duration
+
+	connMu       sync.Mutex
+	conn         Transport
+	reconnect    *ReconnectOpts
+	reconnecting chan struct{} // closed when an in-progress reconnect ends
+	closing      bool          // set by Close to suppress reconnecting
+	browserGone  bool          // set by BrowserClose to suppress reconnecting
+	sessionCaps  *Capabilities // set once NewSession succeeds
+	verbose      bool
+	wireLogger   func(direction string, data []byte)
+
+	reconnectMu       sync.Mutex
+	reconnectHandlers []func()
+
+	nextID      int64 // last command id, accessed atomically
+	readOnce    sync.Once
+	readStarted int32         // set once the read loop starts, accessed atomically
+	readDone    chan struct{} // closed when the read loop exits
+	events      *eventQueue
+
+	pendingMu sync.Mutex
+	pending   map[int64]chan *Message // command id -> response channel
+	readErr   error                   // set when the read loop stops
+
+	handlersMu    sync.RWMutex
+	handlers      map[string][]eventHandler // event method -> handlers
+	nextHandlerID int64
+
+	subscriptionsMu sync.Mutex
+	subscriptions   []Subscription
+	sharedSubs      map[string]int // event and contexts -> subscribe calls without an id
+
+	preloadMu sync.Mutex
+	preloads  map[string]*preloadScript // original id -> script
+
+	contextsMu sync.Mutex
+	tracking   bool          // set once TrackContexts has seeded contexts
+	trackReady chan struct{} // closed when a TrackContexts call finishes
+	contexts   []ContextInfo // top-level contexts in the order opened
 }
 
-// NewClient creates a new BiDi client from a WebSocket connection.
-func NewClient(conn *Connection) *Client {
-	return &Client{conn: conn}
+// NewClient creates a new BiDi client from a transport such as a WebSocket
+// connection or a PipeTransport.
+func NewClient(conn Transport) *Client {
+	return &Client{
+		conn:     conn,
+		readDone: make(chan struct{}),
+		events:   newEventQueue(),
+		pending:  make(map[int64]chan *Message),
+		handlers: make(map[string][]eventHandler),
+		preloads: make(map[string]*preloadScript),
+	}
 }
 
 // SetVerbose enables or disables verbose logging of JSON messages.
@@ -21,75 +82,272 @@ func (c *Client) SetVerbose(verbose bool) {
 	c.verbose = verbose
 }
 
-// SendCommand sends a BiDi command and waits for the response.
-func (c *Client) SendCommand(method string, params interface{}) (*Message, error) {
-	cmd := NewCommand(method, params)
+// Wire logger directions.
+const (
+	WireSend    = "send"
+	WireReceive = "receive"
+)
 
-	data, err := cmd.Marshal()
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal command: %w", err)
-	}
+// SetWireLogger sets a function called with every frame sent or received,
+// or clears it if logger is nil. It is called synchronously on the sending
+// goroutine and on the read loop, so it must return quickly; a slow logger
+// delays every response. data must not be retained after it returns.
+func (c *Client) SetWireLogger(logger func(direction string, data []byte)) {
+	c.connMu.Lock()
+	c.wireLogger = logger
+	c.connMu.Unlock()
+}
 
-	if c.verbose {
-		fmt.Printf("       --> %s\n", string(data))
-	}
+// logWire passes a frame to the wire logger, if one is set.
+func (c *Client) logWire(direction string, data []byte) {
+	c.connMu.Lock()
+	logger := c.wireLogger
+	c.connMu.Unlock()
 
-	if err := c.conn.Send(string(data)); err != nil {
-		return nil, fmt.Errorf("failed to send command: %w", err)
+	if logger != nil {
+		logger(direction, data)
 	}
+}
+
+// startReadLoop starts the background reader and event dispatcher once.
+func (c *Client) startReadLoop() {
+	c.readOnce.Do(func() {
+		atomic.StoreInt32(&c.readStarted, 1)
+		go c.readLoop()
+		go c.dispatchLoop()
+	})
+}
+
+// readLoop reads frames from the connection, delivering responses to their
+// waiting commands and queueing events for dispatch.
+func (c *Client) readLoop() {
+	defer close(c.readDone)
 
-	// Wait for response with matching ID
 	for {
-		resp, err := c.conn.Receive()
+		conn := c.currentConn()
+		data, err := conn.Receive()
 		if err != nil {
-			return nil, fmt.Errorf("failed to receive response: %w", err)
+			if c.shouldReconnect(conn) {
+				if c.reconnectLoop(conn, err) {
+					continue
+				}
+				c.events.close()
+				return
+			}
+			c.failPending(fmt.Errorf("failed to receive response: %w", err))
+			c.events.close()
+			return
 		}
 
 		if c.verbose {
-			fmt.Printf("       <-- %s\n", resp)
+			fmt.Printf("       <-- %s\n", data)
 		}
+		c.logWire(WireReceive, []byte(data))
 
-		msg, err := UnmarshalMessage([]byte(resp))
+		msg, err := UnmarshalMessage([]byte(data))
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
+			continue
 		}
 
-		// Check if this is the response we're waiting for
-		if msg.ID != nil && *msg.ID == cmd.ID {
-			if msg.IsError() {
-				errData, _ := msg.GetError()
-				if errData != nil {
-					return nil, fmt.Errorf("BiDi error: %s - %s", errData.Error, errData.Message)
-				}
-				return nil, fmt.Errorf("BiDi error: %s", string(msg.Error))
+		if msg.IsResponse() {
+			c.pendingMu.Lock()
+			ch, ok := c.pending[*msg.ID]
+			delete(c.pending, *msg.ID)
+			c.pendingMu.Unlock()
+
+			if ok {
+				ch <- msg
+			} else if c.verbose {
+				fmt.Printf("       (response for unknown id %d, dropping)\n", *msg.ID)
 			}
-			return msg, nil
+			continue
 		}
 
-		// If it's an event, skip it for now (could be handled by event listener)
 		if msg.IsEvent() {
-			if c.verbose {
-				fmt.Printf("       (event, skipping)\n")
+			c.events.push(msg)
+		}
+	}
+}
+
+// failPending records the read error and wakes every waiting command. Only
+// the first error is kept, so a shutdown error is not replaced by the read
+// error it causes.
+func (c *Client) failPending(err error) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	if c.readErr == nil {
+		c.readErr = err
+	}
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+}
+
+// SendCommand sends a BiDi command and waits for the response, for at most
+// DefaultTimeout if it is set. params is encoded with encoding/json and may
+// be any value that encodes to a JSON object. It can be used for any BiDi
+// command, including ones without a dedicated method:
+//
+//	msg, err := client.SendCommand("emulation.setForcedColorsModeThemeOverride",
+//		map[string]interface{}{"theme": "dark"})
+//
+// The returned Message holds the raw result in msg.Result. An error response
+// is returned as an *errors.BiDiError carrying the BiDi error code.
+func (c *Client) SendCommand(method string, params interface{}) (*Message, error) {
+	if c.DefaultTimeout <= 0 {
+		return c.SendCommandContext(context.Background(), method, params)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.DefaultTimeout)
+	defer cancel()
+
+	msg, err := c.SendCommandContext(ctx, method, params)
+	if err == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %s: %w", method, c.DefaultTimeout, err)
+	}
+	return msg, err
+}
+
+// SendCommandRaw sends a command whose params are already JSON-encoded.
+// Empty params are sent as an empty object.
+func (c *Client) SendCommandRaw(method string, params json.RawMessage) (*Message, error) {
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	if !json.Valid(params) {
+		return nil, fmt.Errorf("invalid JSON params for %s", method)
+	}
+	return c.SendCommand(method, params)
+}
+
+// SendCommandContext sends a BiDi command and waits for the response or for
+// ctx to be done, in which case it stops waiting and returns ctx.Err().
+// While the client is reconnecting, the command waits until the new
+// connection is ready.
+func (c *Client) SendCommandContext(ctx context.Context, method string, params interface{}) (*Message, error) {
+	if err := c.waitReconnect(ctx); err != nil {
+		return nil, err
+	}
+	return c.send(ctx, method, params)
+}
+
+// send sends a command on the current connection without waiting for a
+// reconnect to finish.
+func (c *Client) send(ctx context.Context, method string, params interface{}) (*Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.startReadLoop()
+
+	// Ids increase monotonically per client, so concurrent callers each wait
+	// on their own response
+	cmd := &Command{
+		ID:     atomic.AddInt64(&c.nextID, 1),
+		Method: method,
+		Params: params,
+	}
+
+	data, err := cmd.Marshal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal command: %w", err)
+	}
+
+	// Register the waiter before sending so a fast response is not missed
+	ch := make(chan *Message, 1)
+	c.pendingMu.Lock()
+	if c.readErr != nil {
+		err := c.readErr
+		c.pendingMu.Unlock()
+		return nil, err
+	}
+	c.pending[cmd.ID] = ch
+	c.pendingMu.Unlock()
+
+	if c.verbose {
+		fmt.Printf("       --> %s\n", string(data))
+	}
+	c.logWire(WireSend, data)
+
+	if err := c.currentConn().Send(string(data)); err != nil {
+		c.pendingMu.Lock()
+		delete(c.pending, cmd.ID)
+		c.pendingMu.Unlock()
+		return nil, fmt.Errorf("failed to send command: %w", err)
+	}
+
+	var msg *Message
+	select {
+	case m, ok := <-ch:
+		if !ok {
+			c.pendingMu.Lock()
+			err := c.readErr
+			c.pendingMu.Unlock()
+			if err == nil {
+				err = errConnectionLost
+			}
+			return nil, err
+		}
+		msg = m
+	case <-ctx.Done():
+		c.pendingMu.Lock()
+		delete(c.pending, cmd.ID)
+		c.pendingMu.Unlock()
+		return nil, ctx.Err()
+	}
+
+	if msg.IsError() {
+		errData, _ := msg.GetError()
+		if errData != nil {
+			return nil, &errs.BiDiError{
+				Code:       errData.Error,
+				Message:    errData.Message,
+				Stacktrace: errData.Stacktrace,
 			}
-			continue
 		}
+		return nil, &errs.BiDiError{Code: string(msg.Error)}
+	}
+	return msg, nil
+}
+
+// SendCommandsConcurrent sends several independent commands without waiting
+// for each response in turn and returns their responses and errors in the
+// same order as cmds. Command ids are assigned by the client, so the ID
+// field of each command is ignored.
+func (c *Client) SendCommandsConcurrent(cmds []Command) ([]*Message, []error) {
+	msgs := make([]*Message, len(cmds))
+	cmdErrs := make([]error, len(cmds))
+
+	var wg sync.WaitGroup
+	for i, cmd := range cmds {
+		wg.Add(1)
+		go func(i int, cmd Command) {
+			defer wg.Done()
+			msgs[i], cmdErrs[i] = c.SendCommand(cmd.Method, cmd.Params)
+		}(i, cmd)
 	}
+	wg.Wait()
+
+	return msgs, cmdErrs
 }
 
-// SessionStatusResult represents the result of session.status command.
-type SessionStatusResult struct {
+// SessionStatus represents the result of session.status command.
+type SessionStatus struct {
 	Ready   bool   `json:"ready"`
 	Message string `json:"message"`
 }
 
-// SessionStatus sends a session.status command and returns the result.
-func (c *Client) SessionStatus() (*SessionStatusResult, error) {
+// Status sends a session.status command and returns the result. It does not
+// require a session, so it can be polled to wait for the browser to start.
+func (c *Client) Status() (*SessionStatus, error) {
 	msg, err := c.SendCommand("session.status", map[string]interface{}{})
 	if err != nil {
 		return nil, err
 	}
 
-	var result SessionStatusResult
+	var result SessionStatus
 	if err := json.Unmarshal(msg.Result, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse session.status result: %w", err)
 	}
@@ -97,16 +355,103 @@ func (c *Client) SessionStatus() (*SessionStatusResult, error) {
 	return &result, nil
 }
 
-// SessionNewResult represents the result of session.new command.
-type SessionNewResult struct {
-	SessionID    string                 `json:"sessionId"`
-	Capabilities map[string]interface{} `json:"capabilities"`
+// ProxyConfiguration configures the browser's proxy.
+type ProxyConfiguration struct {
+	ProxyType          string   `json:"proxyType"` // "direct", "manual", "pac", "autodetect" or "system"
+	ProxyAutoconfigURL string   `json:"proxyAutoconfigUrl,omitempty"`
+	HTTPProxy          string   `json:"httpProxy,omitempty"`
+	SSLProxy           string   `json:"sslProxy,omitempty"`
+	SOCKSProxy         string   `json:"socksProxy,omitempty"`
+	SOCKSVersion       int      `json:"socksVersion,omitempty"`
+	NoProxy            []string `json:"noProxy,omitempty"`
+}
+
+// UserPromptHandler sets how unhandled prompts of each type are answered:
+// "accept", "dismiss" or "ignore". Default applies to unset types.
+type UserPromptHandler struct {
+	Alert        string `json:"alert,omitempty"`
+	BeforeUnload string `json:"beforeUnload,omitempty"`
+	Confirm      string `json:"confirm,omitempty"`
+	Default      string `json:"default,omitempty"`
+	File         string `json:"file,omitempty"`
+	Prompt       string `json:"prompt,omitempty"`
+}
+
+// CapabilityRequest is a set of capabilities requested for a new session.
+// Extensions holds vendor capabilities such as "goog:chromeOptions".
+type CapabilityRequest struct {
+	AcceptInsecureCerts     *bool
+	BrowserName             string
+	BrowserVersion          string
+	PlatformName            string
+	Proxy                   *ProxyConfiguration
+	UnhandledPromptBehavior *UserPromptHandler
+	WebSocketURL            bool
+	Extensions              map[string]interface{}
+}
+
+// MarshalJSON merges the extension capabilities with the standard ones.
+func (r CapabilityRequest) MarshalJSON() ([]byte, error) {
+	caps := make(map[string]interface{}, len(r.Extensions)+7)
+	for name, value := range r.Extensions {
+		caps[name] = value
+	}
+	if r.AcceptInsecureCerts != nil {
+		caps["acceptInsecureCerts"] = *r.AcceptInsecureCerts
+	}
+	if r.BrowserName != "" {
+		caps["browserName"] = r.BrowserName
+	}
+	if r.BrowserVersion != "" {
+		caps["browserVersion"] = r.BrowserVersion
+	}
+	if r.PlatformName != "" {
+		caps["platformName"] = r.PlatformName
+	}
+	if r.Proxy != nil {
+		caps["proxy"] = r.Proxy
+	}
+	if r.UnhandledPromptBehavior != nil {
+		caps["unhandledPromptBehavior"] = r.UnhandledPromptBehavior
+	}
+	if r.WebSocketURL {
+		caps["webSocketUrl"] = true
+	}
+	return json.Marshal(caps)
+}
+
+// Capabilities is the capabilities request for session.new. The browser
+// merges AlwaysMatch with each FirstMatch entry in turn and uses the first
+// combination it can satisfy.
+type Capabilities struct {
+	AlwaysMatch *CapabilityRequest  `json:"alwaysMatch,omitempty"`
+	FirstMatch  []CapabilityRequest `json:"firstMatch,omitempty"`
 }
 
-// SessionNew sends a session.new command and returns the result.
-func (c *Client) SessionNew(capabilities map[string]interface{}) (*SessionNewResult, error) {
+// SessionCapabilities are the capabilities negotiated for a session.
+type SessionCapabilities struct {
+	AcceptInsecureCerts     bool                `json:"acceptInsecureCerts"`
+	BrowserName             string              `json:"browserName"`
+	BrowserVersion          string              `json:"browserVersion"`
+	PlatformName            string              `json:"platformName"`
+	SetWindowRect           bool                `json:"setWindowRect"`
+	UserAgent               string              `json:"userAgent"`
+	Proxy                   *ProxyConfiguration `json:"proxy,omitempty"`
+	UnhandledPromptBehavior *UserPromptHandler  `json:"unhandledPromptBehavior,omitempty"`
+	WebSocketURL            string              `json:"webSocketUrl,omitempty"`
+}
+
+// SessionResult represents the result of session.new command.
+type SessionResult struct {
+	SessionID    string              `json:"sessionId"`
+	Capabilities SessionCapabilities `json:"capabilities"`
+}
+
+// NewSession sends a session.new command and returns the session id and
+// negotiated capabilities.
+func (c *Client) NewSession(caps Capabilities) (*SessionResult, error) {
 	params := map[string]interface{}{
-		"capabilities": capabilities,
+		"capabilities": caps,
 	}
 
 	msg, err := c.SendCommand("session.new", params)
@@ -114,15 +459,259 @@ func (c *Client) SessionNew(capabilities map[string]interface{}) (*SessionNewRes
 		return nil, err
 	}
 
-	var result SessionNewResult
+	var result SessionResult
 	if err := json.Unmarshal(msg.Result, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse session.new result: %w", err)
 	}
 
+	// Remember the capabilities so a reconnect can start a new session
+	c.connMu.Lock()
+	c.sessionCaps = &caps
+	c.connMu.Unlock()
+
 	return &result, nil
 }
 
-// Close closes the underlying connection.
+// Subscription records an active session.subscribe call.
+type Subscription struct {
+	ID       string   // subscription id, if the browser returned one
+	Events   []string // event names or modules
+	Contexts []string // empty for a global subscription
+}
+
+// SubscribeResult represents the result of session.subscribe.
+type SubscribeResult struct {
+	Subscription string `json:"subscription,omitempty"`
+}
+
+// Subscribe enables delivery of the given events (e.g. "log.entryAdded" or a
+// whole module like "network"). If contexts is empty, it subscribes globally.
+func (c *Client) Subscribe(events []string, contexts []string) error {
+	_, err := c.subscribe(events, contexts)
+	return err
+}
+
+// subscribe sends session.subscribe and returns the subscription id, which is
+// empty if the browser does not support subscription ids.
+func (c *Client) subscribe(events []string, contexts []string) (string, error) {
+	if len(events) == 0 {
+		return "", fmt.Errorf("no events to subscribe to")
+	}
+
+	params := map[string]interface{}{
+		"events": events,
+	}
+	if len(contexts) > 0 {
+		params["contexts"] = contexts
+	}
+
+	msg, err := c.SendCommand("session.subscribe", params)
+	if err != nil {
+		return "", err
+	}
+
+	// Older implementations return an empty result without a subscription id
+	var result SubscribeResult
+	if len(msg.Result) > 0 {
+		if err := json.Unmarshal(msg.Result, &result); err != nil {
+			return "", fmt.Errorf("failed to parse session.subscribe result: %w", err)
+		}
+	}
+
+	c.subscriptionsMu.Lock()
+	c.subscriptions = append(c.subscriptions, Subscription{
+		ID:       result.Subscription,
+		Events:   events,
+		Contexts: contexts,
+	})
+	if result.Subscription == "" {
+		if c.sharedSubs == nil {
+			c.sharedSubs = make(map[string]int)
+		}
+		for _, event := range events {
+			c.sharedSubs[subscriptionKey(event, contexts)]++
+		}
+	}
+	c.subscriptionsMu.Unlock()
+
+	return result.Subscription, nil
+}
+
+// releaseSubscription undoes one subscribe call. With an id it unsubscribes
+// by id. Without one, the browser keeps a single subscription per event and
+// contexts for every caller, so it is only unsubscribed once the last caller
+// that subscribed to it releases it.
+func (c *Client) releaseSubscription(id string, events []string, contexts []string) error {
+	if id != "" {
+		return c.UnsubscribeByID([]string{id})
+	}
+
+	c.subscriptionsMu.Lock()
+	var unused []string
+	for _, event := range events {
+		key := subscriptionKey(event, contexts)
+		if c.sharedSubs[key]--; c.sharedSubs[key] <= 0 {
+			delete(c.sharedSubs, key)
+			unused = append(unused, event)
+		}
+	}
+	for i, sub := range c.subscriptions {
+		if sub.ID == "" && sameStrings(sub.Events, events) && sameStrings(sub.Contexts, contexts) {
+			c.subscriptions = append(c.subscriptions[:i], c.subscriptions[i+1:]...)
+			break
+		}
+	}
+	c.subscriptionsMu.Unlock()
+
+	if len(unused) == 0 {
+		return nil
+	}
+	return c.Unsubscribe(unused, contexts)
+}
+
+// subscriptionKey identifies an event subscription in a set of contexts.
+func subscriptionKey(event string, contexts []string) string {
+	return event + "\x00" + strings.Join(contexts, "\x00")
+}
+
+// Unsubscribe stops delivery of the given events. If contexts is empty, it
+// removes a global subscription. The browser rejects events that were never
+// subscribed to and that error is returned as-is.
+func (c *Client) Unsubscribe(events []string, contexts []string) error {
+	if len(events) == 0 {
+		return fmt.Errorf("no events to unsubscribe from")
+	}
+
+	params := map[string]interface{}{
+		"events": events,
+	}
+	if len(contexts) > 0 {
+		params["contexts"] = contexts
+	}
+
+	if _, err := c.SendCommand("session.unsubscribe", params); err != nil {
+		return err
+	}
+
+	removed := make(map[string]bool, len(events))
+	for _, event := range events {
+		removed[event] = true
+	}
+
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+
+	for _, event := range events {
+		delete(c.sharedSubs, subscriptionKey(event, contexts))
+	}
+
+	kept := c.subscriptions[:0]
+	for _, sub := range c.subscriptions {
+		if sameStrings(sub.Contexts, contexts) {
+			remaining := make([]string, 0, len(sub.Events))
+			for _, event := range sub.Events {
+				if !removed[event] {
+					remaining = append(remaining, event)
+				}
+			}
+			if len(remaining) == 0 {
+				continue
+			}
+			sub.Events = remaining
+		}
+		kept = append(kept, sub)
+	}
+	c.subscriptions = kept
+
+	return nil
+}
+
+// UnsubscribeByID removes subscriptions by the ids returned from session.subscribe.
+func (c *Client) UnsubscribeByID(ids []string) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no subscription ids to unsubscribe")
+	}
+
+	params := map[string]interface{}{
+		"subscriptions": ids,
+	}
+
+	if _, err := c.SendCommand("session.unsubscribe", params); err != nil {
+		return err
+	}
+
+	removed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		removed[id] = true
+	}
+
+	c.subscriptionsMu.Lock()
+	defer c.subscriptionsMu.Unlock()
+
+	kept := c.subscriptions[:0]
+	for _, sub := range c.subscriptions {
+		if sub.ID == "" || !removed[sub.ID] {
+			kept = append(kept, sub)
+		}
+	}
+	c.subscriptions = kept
+
+	return nil
+}
+
+// sameStrings reports whether two string slices contain the same elements in order.
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrClientClosed is returned to commands that were pending or sent after Close.
+var ErrClientClosed = errors.New("client closed")
+
+// closeTimeout bounds how long Close waits for session.end.
+const closeTimeout = 5 * time.Second
+
+// Close shuts the client down. If the client started the session with
+// NewSession, it first ends it with session.end. It then fails pending
+// commands with ErrClientClosed, closes the connection and waits for the
+// read loop to exit. Close is idempotent.
 func (c *Client) Close() error {
-	return c.conn.Close()
+	c.connMu.Lock()
+	if c.closing {
+		c.connMu.Unlock()
+		return nil
+	}
+	c.closing = true
+	conn := c.conn
+	endSession := c.sessionCaps != nil && !c.browserGone
+	c.connMu.Unlock()
+
+	var endErr error
+	if endSession {
+		ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+		_, endErr = c.send(ctx, "session.end", map[string]interface{}{})
+		cancel()
+	}
+
+	c.failPending(ErrClientClosed)
+	err := conn.Close()
+
+	if atomic.LoadInt32(&c.readStarted) == 1 {
+		<-c.readDone
+	}
+
+	if err != nil {
+		return err
+	}
+	if endErr != nil {
+		return fmt.Errorf("failed to end session: %w", endErr)
+	}
+	return nil
 }
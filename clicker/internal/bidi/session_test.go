@@ -0,0 +1,144 @@
+package bidi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// fakeTransport hands sent commands to the test and delivers the replies it
+// queues, so responses can arrive in any order.
+type fakeTransport struct {
+	sent    chan string
+	replies chan string
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{
+		sent:    make(chan string, 64),
+		replies: make(chan string, 64),
+		done:    make(chan struct{}),
+	}
+}
+
+func (t *fakeTransport) Send(msg string) error {
+	select {
+	case t.sent <- msg:
+		return nil
+	case <-t.done:
+		return errors.New("transport closed")
+	}
+}
+
+func (t *fakeTransport) Receive() (string, error) {
+	select {
+	case msg := <-t.replies:
+		return msg, nil
+	case <-t.done:
+		return "", errors.New("transport closed")
+	}
+}
+
+func (t *fakeTransport) Close() error {
+	t.once.Do(func() { close(t.done) })
+	return nil
+}
+
+func TestSendCommandConcurrentOutOfOrder(t *testing.T) {
+	const n = 20
+
+	transport := newFakeTransport()
+	defer transport.Close()
+	client := NewClient(transport)
+
+	type result struct {
+		caller int
+		value  int
+		err    error
+	}
+	results := make(chan result, n)
+
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			msg, err := client.SendCommand("test.echo", map[string]interface{}{"value": i})
+			if err != nil {
+				results <- result{caller: i, err: err}
+				return
+			}
+			var res struct {
+				Value int `json:"value"`
+			}
+			if err := json.Unmarshal(msg.Result, &res); err != nil {
+				results <- result{caller: i, err: err}
+				return
+			}
+			results <- result{caller: i, value: res.Value}
+		}(i)
+	}
+
+	// Collect every command before replying, then answer newest first.
+	type command struct {
+		ID     int64 `json:"id"`
+		Params struct {
+			Value int `json:"value"`
+		} `json:"params"`
+	}
+	commands := make([]command, 0, n)
+	for i := 0; i < n; i++ {
+		var cmd command
+		if err := json.Unmarshal([]byte(<-transport.sent), &cmd); err != nil {
+			t.Fatalf("unmarshal command: %v", err)
+		}
+		commands = append(commands, cmd)
+	}
+	for i := len(commands) - 1; i >= 0; i-- {
+		cmd := commands[i]
+		transport.replies <- fmt.Sprintf(`{"id":%d,"type":"success","result":{"value":%d}}`, cmd.ID, cmd.Params.Value)
+	}
+
+	for i := 0; i < n; i++ {
+		r := <-results
+		if r.err != nil {
+			t.Errorf("caller %d: %v", r.caller, r.err)
+			continue
+		}
+		if r.value != r.caller {
+			t.Errorf("caller %d got result %d", r.caller, r.value)
+		}
+	}
+}
+
+// fakeCommand is a command as the fake browser receives it.
+type fakeCommand struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// serveFake answers every command sent on transport with the reply built by
+// respond, until the transport closes.
+func serveFake(transport *fakeTransport, respond func(cmd fakeCommand) string) {
+	go func() {
+		for {
+			select {
+			case msg := <-transport.sent:
+				var cmd fakeCommand
+				if err := json.Unmarshal([]byte(msg), &cmd); err != nil {
+					return
+				}
+				transport.replies <- respond(cmd)
+			case <-transport.done:
+				return
+			}
+		}
+	}()
+}
+
+// success builds an empty success reply to cmd.
+func success(cmd fakeCommand) string {
+	return fmt.Sprintf(`{"id":%d,"type":"success","result":{}}`, cmd.ID)
+}
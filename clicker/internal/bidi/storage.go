@@ -0,0 +1,122 @@
+package bidi
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PartitionDescriptor selects a cookie storage partition. With Type
+// "context", Context names a browsing context whose partition is used;
+// with Type "storageKey", UserContext and SourceOrigin identify it.
+type PartitionDescriptor struct {
+	Type         string `json:"type"` // "context" or "storageKey"
+	Context      string `json:"context,omitempty"`
+	UserContext  string `json:"userContext,omitempty"`
+	SourceOrigin string `json:"sourceOrigin,omitempty"`
+}
+
+// CookieFilter selects cookies by the fields that are set. Unset fields
+// match any cookie. Partition selects the partition; nil means the default.
+type CookieFilter struct {
+	Name      string
+	Domain    string
+	Path      string
+	Partition *PartitionDescriptor
+}
+
+// serialize returns the storage command params for the filter.
+func (f CookieFilter) serialize() map[string]interface{} {
+	filter := map[string]interface{}{}
+	if f.Name != "" {
+		filter["name"] = f.Name
+	}
+	if f.Domain != "" {
+		filter["domain"] = f.Domain
+	}
+	if f.Path != "" {
+		filter["path"] = f.Path
+	}
+
+	params := map[string]interface{}{
+		"filter": filter,
+	}
+	if f.Partition != nil {
+		params["partition"] = f.Partition
+	}
+	return params
+}
+
+// GetCookiesResult represents the result of storage.getCookies.
+type GetCookiesResult struct {
+	Cookies []Cookie `json:"cookies"`
+}
+
+// GetCookies returns the cookies matching the filter.
+func (c *Client) GetCookies(filter CookieFilter) ([]Cookie, error) {
+	msg, err := c.SendCommand("storage.getCookies", filter.serialize())
+	if err != nil {
+		return nil, err
+	}
+
+	var result GetCookiesResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse storage.getCookies result: %w", err)
+	}
+
+	return result.Cookies, nil
+}
+
+// SetCookie stores a cookie in the given partition, or the default partition
+// if partition is nil. Name, Value and Domain are required; Size is ignored.
+// Invalid combinations, such as SameSite "none" without Secure, are rejected
+// by the browser.
+func (c *Client) SetCookie(cookie Cookie, partition *PartitionDescriptor) error {
+	if cookie.Name == "" || cookie.Domain == "" {
+		return fmt.Errorf("cookie name and domain are required")
+	}
+
+	partial := map[string]interface{}{
+		"name":   cookie.Name,
+		"value":  cookie.Value,
+		"domain": cookie.Domain,
+	}
+	if cookie.Value.Type == "" {
+		partial["value"] = StringValue(cookie.Value.Value)
+	}
+	if cookie.Path != "" {
+		partial["path"] = cookie.Path
+	}
+	if cookie.HTTPOnly {
+		partial["httpOnly"] = true
+	}
+	if cookie.Secure {
+		partial["secure"] = true
+	}
+	switch cookie.SameSite {
+	case "":
+	case "strict", "lax", "none":
+		partial["sameSite"] = cookie.SameSite
+	default:
+		return fmt.Errorf("invalid sameSite value: %s", cookie.SameSite)
+	}
+	if cookie.Expiry != nil {
+		partial["expiry"] = *cookie.Expiry
+	}
+
+	params := map[string]interface{}{
+		"cookie": partial,
+	}
+	if partition != nil {
+		params["partition"] = partition
+	}
+
+	_, err := c.SendCommand("storage.setCookie", params)
+	return err
+}
+
+// DeleteCookies deletes the cookies matching the filter. An empty filter
+// deletes every cookie in the default partition.
+func (c *Client) DeleteCookies(filter CookieFilter) error {
+	_, err := c.SendCommand("storage.deleteCookies", filter.serialize())
+	return err
+}
@@ -0,0 +1,109 @@
+package bidi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Transport carries BiDi messages to and from the browser. Connection is
+// the WebSocket implementation; PipeTransport reads and writes a pair of
+// streams such as a child process's stdio.
+type Transport interface {
+	// Send sends one message.
+	Send(msg string) error
+
+	// Receive blocks until a message arrives.
+	Receive() (string, error)
+
+	// Close closes the transport, unblocking Receive.
+	Close() error
+}
+
+// PipeTransport exchanges NUL-terminated messages over a pair of streams.
+type PipeTransport struct {
+	r *bufio.Reader
+	w io.Writer
+
+	mu     sync.Mutex
+	closed bool
+	closer []io.Closer
+}
+
+// NewPipeTransport creates a transport that reads messages from r and writes
+// them to w. If r or w implement io.Closer, they are closed by Close.
+func NewPipeTransport(r io.Reader, w io.Writer) *PipeTransport {
+	t := &PipeTransport{
+		r: bufio.NewReaderSize(r, 64*1024),
+		w: w,
+	}
+	if c, ok := w.(io.Closer); ok {
+		t.closer = append(t.closer, c)
+	}
+	if c, ok := r.(io.Closer); ok {
+		t.closer = append(t.closer, c)
+	}
+	return t
+}
+
+// Send writes a message followed by a NUL terminator.
+func (t *PipeTransport) Send(msg string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return fmt.Errorf("pipe closed")
+	}
+
+	if _, err := io.WriteString(t.w, msg+"\x00"); err != nil {
+		return fmt.Errorf("failed to write to pipe: %w", err)
+	}
+	return nil
+}
+
+// Receive reads the next NUL-terminated message. It reads in chunks and
+// fails as soon as the message exceeds maxMessageSize, so a peer that never
+// sends a terminator cannot exhaust memory.
+func (t *PipeTransport) Receive() (string, error) {
+	var data []byte
+	for {
+		chunk, err := t.r.ReadSlice(0)
+		size := len(data) + len(chunk)
+		if err == nil {
+			size-- // the terminator
+		}
+		if size > maxMessageSize {
+			return "", fmt.Errorf("message exceeds %d bytes", maxMessageSize)
+		}
+		data = append(data, chunk...)
+
+		switch err {
+		case nil:
+			return string(data[:len(data)-1]), nil
+		case bufio.ErrBufferFull:
+			continue
+		default:
+			return "", err
+		}
+	}
+}
+
+// Close closes both streams where possible.
+func (t *PipeTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+
+	var firstErr error
+	for _, c := range t.closer {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
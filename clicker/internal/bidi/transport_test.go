@@ -0,0 +1,50 @@
+package bidi
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPipeTransportReceive(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	transport := NewPipeTransport(strings.NewReader("first\x00"+long+"\x00"), io.Discard)
+
+	for _, want := range []string{"first", long} {
+		got, err := transport.Receive()
+		if err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+		if got != want {
+			t.Errorf("Receive returned %d bytes, want %d", len(got), len(want))
+		}
+	}
+	if _, err := transport.Receive(); err != io.EOF {
+		t.Errorf("Receive at end = %v, want io.EOF", err)
+	}
+}
+
+// endlessReader yields bytes that never include a NUL terminator.
+type endlessReader struct {
+	read int
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'x'
+	}
+	r.read += len(p)
+	return len(p), nil
+}
+
+func TestPipeTransportReceiveLimit(t *testing.T) {
+	r := &endlessReader{}
+	transport := NewPipeTransport(r, io.Discard)
+
+	if _, err := transport.Receive(); err == nil {
+		t.Fatal("Receive of an unterminated stream succeeded")
+	}
+	if limit := maxMessageSize + 128*1024; r.read > limit {
+		t.Errorf("read %d bytes before failing, want at most %d", r.read, limit)
+	}
+}
@@ -0,0 +1,281 @@
+package bidi
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// StringPattern returns a URLPattern matching exactly one URL.
+func StringPattern(rawURL string) URLPattern {
+	return URLPattern{Type: "string", Pattern: rawURL}
+}
+
+// ComponentPattern returns a URLPattern that matches every URL. Narrow it
+// with the With* methods; components left unset match anything.
+func ComponentPattern() URLPattern {
+	return URLPattern{Type: "pattern"}
+}
+
+// WithProtocol returns a copy of p that only matches the given scheme.
+func (p URLPattern) WithProtocol(protocol string) URLPattern {
+	p.Protocol = strings.TrimSuffix(protocol, ":")
+	return p
+}
+
+// WithHostname returns a copy of p that only matches the given host.
+func (p URLPattern) WithHostname(hostname string) URLPattern {
+	p.Hostname = hostname
+	return p
+}
+
+// WithPort returns a copy of p that only matches the given port.
+func (p URLPattern) WithPort(port string) URLPattern {
+	p.Port = port
+	return p
+}
+
+// WithPathname returns a copy of p that only matches the given path.
+func (p URLPattern) WithPathname(pathname string) URLPattern {
+	p.Pathname = pathname
+	return p
+}
+
+// WithSearch returns a copy of p that only matches the given query string.
+func (p URLPattern) WithSearch(search string) URLPattern {
+	p.Search = strings.TrimPrefix(search, "?")
+	return p
+}
+
+// Validate checks that the pattern can be sent to the browser.
+func (p URLPattern) Validate() error {
+	switch p.Type {
+	case "string":
+		if p.Pattern == "" {
+			return fmt.Errorf("string URL pattern is empty")
+		}
+		if p.Protocol != "" || p.Hostname != "" || p.Port != "" || p.Pathname != "" || p.Search != "" {
+			return fmt.Errorf("string URL pattern cannot set URL components")
+		}
+		return nil
+	case "pattern":
+	default:
+		return fmt.Errorf("invalid URL pattern type: %q", p.Type)
+	}
+
+	if p.Pattern != "" {
+		return fmt.Errorf("component URL pattern cannot set a pattern string")
+	}
+	components := []struct{ name, value string }{
+		{"protocol", p.Protocol},
+		{"hostname", p.Hostname},
+		{"port", p.Port},
+		{"pathname", p.Pathname},
+		{"search", p.Search},
+	}
+	for _, component := range components {
+		switch special := unescapedSpecial(component.value); special {
+		case 0:
+		case '*':
+			return fmt.Errorf("URL pattern %s cannot contain wildcards: %s", component.name, component.value)
+		default:
+			return fmt.Errorf("URL pattern %s cannot contain unescaped %q: %s", component.name, special, component.value)
+		}
+	}
+	if p.Protocol != "" && strings.ContainsAny(p.Protocol, ":/") {
+		return fmt.Errorf("invalid URL pattern protocol: %s", p.Protocol)
+	}
+	if p.Hostname != "" && (strings.Contains(p.Hostname, "/") ||
+		(strings.Contains(p.Hostname, ":") && !strings.HasPrefix(p.Hostname, "["))) {
+		return fmt.Errorf("invalid URL pattern hostname: %s", p.Hostname)
+	}
+	for _, r := range p.Port {
+		if r < '0' || r > '9' {
+			return fmt.Errorf("invalid URL pattern port: %s", p.Port)
+		}
+	}
+	return nil
+}
+
+// unescapedSpecial returns the first character in a component value that
+// the browser rejects unless it is escaped with a backslash, or 0 if none.
+func unescapedSpecial(value string) rune {
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case strings.ContainsRune("*(){}", r):
+			return r
+		}
+	}
+	return 0
+}
+
+// ParseURLPattern converts a URL glob to a URLPattern. A glob without "*" is
+// an exact URL. Otherwise "*" may stand for a whole component, which then
+// matches anything: "https://*/api/users", "*://example.com:*/*" or
+// "https://example.com/api?*". A trailing "/*" path is treated as any path.
+//
+// BiDi patterns match components exactly, so a partial wildcard within a
+// component, as in "https://*.example.com/api/*", is left out of the pattern
+// sent to the browser, which then pauses requests to any host and path. The
+// intercept handler must check MatchURL and continue requests it rejects:
+//
+//	if !pattern.MatchURL(event.Request.URL) {
+//		client.ContinueRequest(bidi.ContinueRequestOpts{Request: event.Request.Request})
+//		return
+//	}
+func ParseURLPattern(glob string) (URLPattern, error) {
+	if glob == "" {
+		return URLPattern{}, fmt.Errorf("URL pattern is empty")
+	}
+
+	if !strings.Contains(glob, "*") {
+		u, err := url.Parse(glob)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return URLPattern{}, fmt.Errorf("invalid URL pattern: %s", glob)
+		}
+		return StringPattern(glob), nil
+	}
+
+	scheme, rest, ok := strings.Cut(glob, "://")
+	if !ok {
+		return URLPattern{}, fmt.Errorf("URL pattern must include a scheme: %s", glob)
+	}
+
+	rest, search, hasSearch := strings.Cut(rest, "?")
+	hostport, path := rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		hostport, path = rest[:i], rest[i:]
+	}
+
+	host, port := hostport, ""
+	if i := strings.LastIndex(hostport, ":"); i >= 0 && !strings.HasSuffix(hostport, "]") {
+		host, port = hostport[:i], hostport[i+1:]
+	}
+	if host == "" {
+		return URLPattern{}, fmt.Errorf("URL pattern is missing a hostname: %s", glob)
+	}
+	if path == "/*" {
+		path = ""
+	}
+	if !hasSearch {
+		search = ""
+	}
+
+	p := ComponentPattern()
+	components := []struct {
+		name, value string
+		set         func(URLPattern, string) URLPattern
+	}{
+		{"protocol", scheme, URLPattern.WithProtocol},
+		{"hostname", host, URLPattern.WithHostname},
+		{"port", port, URLPattern.WithPort},
+		{"pathname", path, URLPattern.WithPathname},
+		{"search", search, URLPattern.WithSearch},
+	}
+	for _, component := range components {
+		switch {
+		case component.value == "" || component.value == "*":
+		case strings.Contains(component.value, "*"):
+			re, err := globRegexp(component.value)
+			if err != nil {
+				return URLPattern{}, fmt.Errorf("invalid URL pattern %s: %w", glob, err)
+			}
+			p.globs = append(p.globs, componentGlob{component: component.name, re: re})
+		default:
+			p = component.set(p, component.value)
+		}
+	}
+
+	if err := p.Validate(); err != nil {
+		return URLPattern{}, fmt.Errorf("invalid URL pattern %s: %w", glob, err)
+	}
+	return p, nil
+}
+
+// componentGlob is a partial wildcard in one URL component, which the
+// browser cannot match and MatchURL checks instead.
+type componentGlob struct {
+	component string
+	re        *regexp.Regexp
+}
+
+// MatchURL reports whether rawURL matches the pattern, including partial
+// wildcards that ParseURLPattern could not send to the browser.
+func (p URLPattern) MatchURL(rawURL string) bool {
+	if p.Type == "string" {
+		return rawURL == p.Pattern
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	actual := urlComponents(u)
+
+	exact := []struct{ name, value string }{
+		{"protocol", p.Protocol},
+		{"hostname", p.Hostname},
+		{"port", normalizePort(p.Protocol, p.Port)},
+		{"pathname", p.Pathname},
+		{"search", p.Search},
+	}
+	for _, component := range exact {
+		if component.value == "" {
+			continue
+		}
+		switch component.name {
+		case "protocol", "hostname":
+			if !strings.EqualFold(actual[component.name], component.value) {
+				return false
+			}
+		default:
+			if actual[component.name] != component.value {
+				return false
+			}
+		}
+	}
+	for _, glob := range p.globs {
+		if !glob.re.MatchString(actual[glob.component]) {
+			return false
+		}
+	}
+	return true
+}
+
+// urlComponents splits a URL into the components a URLPattern matches.
+func urlComponents(u *url.URL) map[string]string {
+	hostname := u.Hostname()
+	if strings.Contains(hostname, ":") {
+		hostname = "[" + hostname + "]"
+	}
+	pathname := u.EscapedPath()
+	if pathname == "" {
+		pathname = "/"
+	}
+	return map[string]string{
+		"protocol": u.Scheme,
+		"hostname": hostname,
+		"port":     normalizePort(u.Scheme, u.Port()),
+		"pathname": pathname,
+		"search":   u.RawQuery,
+	}
+}
+
+// normalizePort returns "" for the default port of scheme, as the browser
+// does when it parses a pattern.
+func normalizePort(scheme, port string) string {
+	switch {
+	case strings.EqualFold(scheme, "http") && port == "80",
+		strings.EqualFold(scheme, "https") && port == "443",
+		strings.EqualFold(scheme, "ws") && port == "80",
+		strings.EqualFold(scheme, "wss") && port == "443":
+		return ""
+	}
+	return port
+}
@@ -0,0 +1,83 @@
+package bidi
+
+import "testing"
+
+func TestURLPatternValidateSpecialCharacters(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern URLPattern
+		wantErr bool
+	}{
+		{"plain path", ComponentPattern().WithPathname("/api/items"), false},
+		{"wildcard", ComponentPattern().WithPathname("/api/*"), true},
+		{"group", ComponentPattern().WithPathname("/api/(items)"), true},
+		{"close paren", ComponentPattern().WithSearch("a=)"), true},
+		{"brace", ComponentPattern().WithHostname("{www.}example.com"), true},
+		{"close brace", ComponentPattern().WithPathname("/a}"), true},
+		{"escaped", ComponentPattern().WithPathname(`/api/\(items\)\*\{\}`), false},
+		{"escaped backslash", ComponentPattern().WithPathname(`/a\\(`), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.pattern.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseURLPatternPartialWildcards(t *testing.T) {
+	p, err := ParseURLPattern("https://*.example.com/api/*")
+	if err != nil {
+		t.Fatalf("ParseURLPattern: %v", err)
+	}
+
+	// The browser gets the broader pattern; the rest is matched client-side
+	if got, want := mustJSON(t, p), `{"type":"pattern","protocol":"https"}`; got != want {
+		t.Errorf("pattern sent to the browser = %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://www.example.com/api/users", true},
+		{"https://a.b.example.com/api/", true},
+		{"https://www.example.com:443/api/users?page=2", true},
+		{"https://example.com/api/users", false},
+		{"https://www.example.com/static/app.js", false},
+		{"http://www.example.com/api/users", false},
+		{"https://evil.com/www.example.com/api/users", false},
+	}
+	for _, tt := range tests {
+		if got := p.MatchURL(tt.url); got != tt.want {
+			t.Errorf("MatchURL(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestParseURLPatternWholeComponents(t *testing.T) {
+	tests := []struct {
+		glob string
+		want string
+	}{
+		{"https://*/api/users", `{"type":"pattern","protocol":"https","pathname":"/api/users"}`},
+		{"*://example.com:*/*", `{"type":"pattern","hostname":"example.com"}`},
+		{"https://example.com/api?*", `{"type":"pattern","protocol":"https","hostname":"example.com","pathname":"/api"}`},
+		{"https://example.com/", `{"type":"string","pattern":"https://example.com/"}`},
+	}
+	for _, tt := range tests {
+		p, err := ParseURLPattern(tt.glob)
+		if err != nil {
+			t.Errorf("ParseURLPattern(%q): %v", tt.glob, err)
+			continue
+		}
+		if got := mustJSON(t, p); got != tt.want {
+			t.Errorf("ParseURLPattern(%q) = %s, want %s", tt.glob, got, tt.want)
+		}
+		if len(p.globs) != 0 {
+			t.Errorf("ParseURLPattern(%q) left %d client-side globs, want none", tt.glob, len(p.globs))
+		}
+	}
+}
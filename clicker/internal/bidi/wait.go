@@ -0,0 +1,427 @@
+package bidi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	errs "github.com/vibium/clicker/internal/errors"
+)
+
+// NavigationWaiter waits for a navigation that may not have started yet.
+// Create it with ExpectNavigation before triggering the navigation, so a
+// navigation that completes quickly is not missed, and defer Stop so it is
+// released even if the navigation is never triggered.
+type NavigationWaiter struct {
+	*eventWaiter
+	events chan NavigationEvent
+}
+
+// readinessEvent returns the event that signals a readiness state.
+func readinessEvent(wait ReadinessState) (string, error) {
+	switch wait {
+	case ReadinessNone:
+		return "browsingContext.navigationStarted", nil
+	case ReadinessInteractive:
+		return "browsingContext.domContentLoaded", nil
+	case "", ReadinessComplete:
+		return "browsingContext.load", nil
+	default:
+		return "", fmt.Errorf("invalid readiness state: %s", wait)
+	}
+}
+
+// ExpectNavigation starts listening for the next navigation in a context to
+// reach the given readiness state. The handler is registered before the
+// subscription is sent, so no event after this call returns can be lost.
+// The context must be a browsing context id. Call Stop when done with the
+// waiter, typically with defer:
+//
+//	w, err := client.ExpectNavigation(context, bidi.ReadinessComplete)
+//	if err != nil {
+//		return err
+//	}
+//	defer w.Stop()
+//	if err := client.Click(context, "a.next"); err != nil {
+//		return err
+//	}
+//	_, err = w.Wait(ctx)
+func (c *Client) ExpectNavigation(context string, wait ReadinessState) (*NavigationWaiter, error) {
+	if context == "" {
+		return nil, fmt.Errorf("context is required")
+	}
+
+	method, err := readinessEvent(wait)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &NavigationWaiter{events: make(chan NavigationEvent, 1)}
+
+	w.eventWaiter, err = c.expectEvent(method, []string{context}, func(params json.RawMessage) {
+		var event NavigationEvent
+		if err := json.Unmarshal(params, &event); err != nil {
+			return
+		}
+		if event.Context != context {
+			return
+		}
+		select {
+		case w.events <- event:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Wait blocks until the expected navigation event fires or ctx is done,
+// then stops listening.
+func (w *NavigationWaiter) Wait(ctx context.Context) (*NavigationEvent, error) {
+	defer w.stop()
+
+	select {
+	case event := <-w.events:
+		return &event, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Stop stops listening for the navigation. It is safe to call more than
+// once and after Wait.
+func (w *NavigationWaiter) Stop() {
+	w.stop()
+}
+
+// WaitForNavigation blocks until the next navigation in a context reaches the
+// given readiness state or ctx is done. It does not trigger a navigation; to
+// avoid missing one that completes quickly, use ExpectNavigation before the
+// action that navigates.
+func (c *Client) WaitForNavigation(ctx context.Context, context string, wait ReadinessState) error {
+	w, err := c.ExpectNavigation(context, wait)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Wait(ctx)
+	return err
+}
+
+// defaultPollInterval is used by the polling waits when no interval is given.
+const defaultPollInterval = 100 * time.Millisecond
+
+// WaitForFunction evaluates expression every interval until it returns a
+// truthy value, which is returned, or until ctx is done. It stops at once if
+// the expression throws. Retryable errors such as a context being swapped
+// during navigation are ignored and the poll continues.
+func (c *Client) WaitForFunction(ctx context.Context, context string, expression string, interval time.Duration) (interface{}, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		value, err := c.EvaluateContext(ctx, context, expression)
+		if err == nil && truthy(value) {
+			return value, nil
+		}
+		if err != nil && ctx.Err() == nil && !isRetryable(err, DefaultRetryableCodes) {
+			return nil, err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for function: %w", ctx.Err())
+		}
+	}
+}
+
+// truthy reports whether a decoded remote value is truthy in JavaScript.
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	case *big.Int:
+		return v.Sign() != 0
+	default:
+		return true
+	}
+}
+
+// WaitOpts configures WaitForSelector.
+type WaitOpts struct {
+	// Interval between polls; zero uses 100ms.
+	Interval time.Duration
+
+	// Hidden waits until no element matches or the first match is hidden,
+	// instead of waiting for a match.
+	Hidden bool
+}
+
+// WaitForSelector polls until an element matches a CSS selector and returns
+// the first match, or until ctx is done. With opts.Hidden, it instead waits
+// until no element matches or the first match is hidden, and returns nil.
+// It returns an *errors.TimeoutError when the ctx deadline passes and the
+// wrapped ctx error when ctx is canceled.
+// If context is empty, it uses the first available context.
+func (c *Client) WaitForSelector(ctx context.Context, context, selector string, opts WaitOpts) (*RemoteValue, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultPollInterval
+	}
+
+	// If no context provided, get the first one from the tree
+	if context == "" {
+		tree, err := c.GetTree()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get browsing context: %w", err)
+		}
+		if len(tree.Contexts) == 0 {
+			return nil, fmt.Errorf("no browsing contexts available")
+		}
+		context = tree.Contexts[0].Context
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		node, done, err := c.pollSelector(ctx, context, selector, opts.Hidden)
+		if done {
+			return node, nil
+		}
+		if err != nil && ctx.Err() == nil && !isRetryable(err, DefaultRetryableCodes) {
+			return nil, err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, selectorWaitError(ctx, selector, start, opts.Hidden)
+		}
+	}
+}
+
+// selectorWaitError reports why a selector wait ended early: a TimeoutError
+// once the deadline passes, or the wrapped ctx error if it was canceled.
+func selectorWaitError(ctx context.Context, selector string, start time.Time, hidden bool) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("waiting for %s canceled: %w", selector, ctx.Err())
+	}
+	return &errs.TimeoutError{
+		Selector: selector,
+		Timeout:  time.Since(start).Round(time.Millisecond),
+		Reason:   waitReason(hidden),
+	}
+}
+
+// pollSelector checks a selector once and reports whether the wait is over.
+func (c *Client) pollSelector(ctx context.Context, context, selector string, hidden bool) (*RemoteValue, bool, error) {
+	nodes, err := c.LocateNodesContext(ctx, context, CSSLocator(selector), LocateNodesOpts{MaxNodeCount: 1})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !hidden {
+		if len(nodes) == 0 {
+			return nil, false, nil
+		}
+		return &nodes[0], true, nil
+	}
+
+	if len(nodes) == 0 {
+		return nil, true, nil
+	}
+	visible, err := c.CallFunctionContext(ctx, context, isVisibleScript, []interface{}{&nodes[0]})
+	if err != nil {
+		// The element was removed after it was located, which is what a
+		// hidden wait is waiting for
+		var bidiErr *errs.BiDiError
+		if errors.As(err, &bidiErr) && bidiErr.Code == "no such node" {
+			return nil, true, nil
+		}
+		return nil, false, err
+	}
+	return nil, visible != true, nil
+}
+
+// waitReason describes what WaitForSelector was waiting for.
+func waitReason(hidden bool) string {
+	if hidden {
+		return "element still visible"
+	}
+	return "element not found"
+}
+
+// eventWaiter holds the registration shared by the navigation and network
+// waiters.
+type eventWaiter struct {
+	client         *Client
+	method         string
+	contexts       []string
+	handlerID      int64
+	subscriptionID string
+	stopOnce       sync.Once
+}
+
+// stop removes the waiter's handler and releases its subscription, leaving
+// other subscriptions to the same event in place. Only the first call has
+// an effect.
+func (w *eventWaiter) stop() {
+	w.stopOnce.Do(func() {
+		w.client.removeHandler(w.method, w.handlerID)
+		w.client.releaseSubscription(w.subscriptionID, []string{w.method}, w.contexts)
+	})
+}
+
+// expectEvent registers handler for method and then subscribes to it in the
+// given contexts, or globally if contexts is empty.
+func (c *Client) expectEvent(method string, contexts []string, handler func(json.RawMessage)) (*eventWaiter, error) {
+	w := &eventWaiter{client: c, method: method, contexts: contexts}
+	w.handlerID = c.addHandler(method, handler)
+
+	var err error
+	w.subscriptionID, err = c.subscribe([]string{method}, contexts)
+	if err != nil {
+		c.removeHandler(method, w.handlerID)
+		return nil, err
+	}
+	return w, nil
+}
+
+// RequestWaiter waits for a request that may not have been sent yet.
+type RequestWaiter struct {
+	*eventWaiter
+	events chan RequestEvent
+}
+
+// ExpectRequest starts listening for the next network.beforeRequestSent
+// event accepted by match. Call it before the action that sends the request,
+// then call Wait. Defer Stop so the waiter is released even if the request
+// is never sent.
+func (c *Client) ExpectRequest(match func(RequestEvent) bool) (*RequestWaiter, error) {
+	w := &RequestWaiter{events: make(chan RequestEvent, 1)}
+
+	var err error
+	w.eventWaiter, err = c.expectEvent("network.beforeRequestSent", nil, func(params json.RawMessage) {
+		var event RequestEvent
+		if err := json.Unmarshal(params, &event); err != nil || !match(event) {
+			return
+		}
+		select {
+		case w.events <- event:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Wait blocks until a matching request is sent or ctx is done, then stops
+// listening.
+func (w *RequestWaiter) Wait(ctx context.Context) (RequestEvent, error) {
+	defer w.stop()
+
+	select {
+	case event := <-w.events:
+		return event, nil
+	case <-ctx.Done():
+		return RequestEvent{}, ctx.Err()
+	}
+}
+
+// Stop stops listening for the request. It is safe to call more than once
+// and after Wait.
+func (w *RequestWaiter) Stop() {
+	w.stop()
+}
+
+// WaitForRequest blocks until a request accepted by match is sent or ctx is
+// done. To avoid missing a request sent quickly, use ExpectRequest before
+// the action that sends it.
+func (c *Client) WaitForRequest(ctx context.Context, match func(RequestEvent) bool) (RequestEvent, error) {
+	w, err := c.ExpectRequest(match)
+	if err != nil {
+		return RequestEvent{}, err
+	}
+	return w.Wait(ctx)
+}
+
+// ResponseWaiter waits for a response that may not have arrived yet.
+type ResponseWaiter struct {
+	*eventWaiter
+	events chan ResponseEvent
+}
+
+// ExpectResponse starts listening for the next network.responseCompleted
+// event accepted by match. Call it before the action that sends the request,
+// then call Wait. Defer Stop so the waiter is released even if the request
+// is never sent.
+func (c *Client) ExpectResponse(match func(ResponseEvent) bool) (*ResponseWaiter, error) {
+	w := &ResponseWaiter{events: make(chan ResponseEvent, 1)}
+
+	var err error
+	w.eventWaiter, err = c.expectEvent("network.responseCompleted", nil, func(params json.RawMessage) {
+		var event ResponseEvent
+		if err := json.Unmarshal(params, &event); err != nil || !match(event) {
+			return
+		}
+		select {
+		case w.events <- event:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Wait blocks until a matching response completes or ctx is done, then
+// stops listening.
+func (w *ResponseWaiter) Wait(ctx context.Context) (ResponseEvent, error) {
+	defer w.stop()
+
+	select {
+	case event := <-w.events:
+		return event, nil
+	case <-ctx.Done():
+		return ResponseEvent{}, ctx.Err()
+	}
+}
+
+// Stop stops listening for the response. It is safe to call more than once
+// and after Wait.
+func (w *ResponseWaiter) Stop() {
+	w.stop()
+}
+
+// WaitForResponse blocks until a response accepted by match completes or ctx
+// is done. To avoid missing a fast response, use ExpectResponse before the
+// action that sends the request.
+func (c *Client) WaitForResponse(ctx context.Context, match func(ResponseEvent) bool) (ResponseEvent, error) {
+	w, err := c.ExpectResponse(match)
+	if err != nil {
+		return ResponseEvent{}, err
+	}
+	return w.Wait(ctx)
+}
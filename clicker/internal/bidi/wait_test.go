@@ -0,0 +1,135 @@
+package bidi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	errs "github.com/vibium/clicker/internal/errors"
+)
+
+func TestEventWaiterStopWithoutSubscriptionID(t *testing.T) {
+	transport := newFakeTransport()
+	defer transport.Close()
+	client := NewClient(transport)
+
+	// Reply like an implementation that returns no subscription ids
+	unsubscribed := make(chan json.RawMessage, 1)
+	serveFake(transport, func(cmd fakeCommand) string {
+		if cmd.Method == "session.unsubscribe" {
+			unsubscribed <- cmd.Params
+		}
+		return success(cmd)
+	})
+
+	w, err := client.ExpectNavigation("ctx-1", ReadinessComplete)
+	if err != nil {
+		t.Fatalf("ExpectNavigation: %v", err)
+	}
+	w.stop()
+
+	want := `{"contexts":["ctx-1"],"events":["browsingContext.load"]}`
+	if got := string(<-unsubscribed); got != want {
+		t.Errorf("session.unsubscribe params = %s, want %s", got, want)
+	}
+	client.subscriptionsMu.Lock()
+	defer client.subscriptionsMu.Unlock()
+	if len(client.subscriptions) != 0 {
+		t.Errorf("subscriptions after stop = %v, want none", client.subscriptions)
+	}
+}
+
+func TestEventWaiterStopKeepsSharedSubscription(t *testing.T) {
+	transport := newFakeTransport()
+	defer transport.Close()
+	client := NewClient(transport)
+
+	var methods []string
+	serveFake(transport, func(cmd fakeCommand) string {
+		methods = append(methods, cmd.Method)
+		return success(cmd)
+	})
+
+	if err := client.OnBeforeRequestSent(func(RequestEvent) {}); err != nil {
+		t.Fatalf("OnBeforeRequestSent: %v", err)
+	}
+	w, err := client.ExpectRequest(func(RequestEvent) bool { return true })
+	if err != nil {
+		t.Fatalf("ExpectRequest: %v", err)
+	}
+	w.stop()
+
+	// Both subscribe calls were answered before stop returned
+	for _, method := range methods {
+		if method == "session.unsubscribe" {
+			t.Errorf("stop unsubscribed the event another listener still uses")
+		}
+	}
+	client.subscriptionsMu.Lock()
+	defer client.subscriptionsMu.Unlock()
+	if len(client.subscriptions) != 1 {
+		t.Errorf("subscriptions after stop = %v, want the listener's one", client.subscriptions)
+	}
+}
+
+func TestEventWaiterStopTwiceReleasesOnce(t *testing.T) {
+	transport := newFakeTransport()
+	defer transport.Close()
+	client := NewClient(transport)
+
+	unsubscribes := 0
+	serveFake(transport, func(cmd fakeCommand) string {
+		if cmd.Method == "session.unsubscribe" {
+			unsubscribes++
+		}
+		return success(cmd)
+	})
+
+	if err := client.OnResponseCompleted(func(ResponseEvent) {}); err != nil {
+		t.Fatalf("OnResponseCompleted: %v", err)
+	}
+	w, err := client.ExpectResponse(func(ResponseEvent) bool { return true })
+	if err != nil {
+		t.Fatalf("ExpectResponse: %v", err)
+	}
+	w.Stop()
+	w.Stop()
+
+	// A second Stop must not release the listener's share as well
+	client.subscriptionsMu.Lock()
+	defer client.subscriptionsMu.Unlock()
+	if unsubscribes != 0 || len(client.subscriptions) != 1 {
+		t.Errorf("after two Stops: %d unsubscribes, subscriptions %v; want 0 and the listener's one",
+			unsubscribes, client.subscriptions)
+	}
+}
+
+func TestWaitForSelectorCancelAndTimeout(t *testing.T) {
+	transport := newFakeTransport()
+	defer transport.Close()
+	client := NewClient(transport)
+
+	// No element ever matches
+	serveFake(transport, func(cmd fakeCommand) string {
+		return fmt.Sprintf(`{"id":%d,"type":"success","result":{"nodes":[]}}`, cmd.ID)
+	})
+	opts := WaitOpts{Interval: 5 * time.Millisecond}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err := client.WaitForSelector(ctx, "ctx-1", "#missing", opts)
+	var timeout *errs.TimeoutError
+	if !errors.Is(err, context.Canceled) || errors.As(err, &timeout) {
+		t.Errorf("canceled wait returned %v, want a wrapped context.Canceled", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.WaitForSelector(ctx, "ctx-1", "#missing", opts)
+	if !errors.As(err, &timeout) {
+		t.Errorf("timed out wait returned %v, want *errors.TimeoutError", err)
+	}
+}
@@ -0,0 +1,92 @@
+package bidi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// ExtensionData identifies an extension to install. Set exactly one of Path
+// (an unpacked extension directory), ArchivePath (a .zip or .xpi file) or
+// Archive (the archive contents).
+type ExtensionData struct {
+	Path        string
+	ArchivePath string
+	Archive     []byte
+}
+
+// serialize returns the webExtension.install extensionData param.
+func (d ExtensionData) serialize() (map[string]interface{}, error) {
+	set := 0
+	data := map[string]interface{}{}
+	if d.Path != "" {
+		set++
+		data["type"] = "path"
+		data["path"] = d.Path
+	}
+	if d.ArchivePath != "" {
+		set++
+		data["type"] = "archivePath"
+		data["path"] = d.ArchivePath
+	}
+	if d.Archive != nil {
+		set++
+		data["type"] = "base64"
+		data["value"] = base64.StdEncoding.EncodeToString(d.Archive)
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("exactly one of path, archive path or archive is required")
+	}
+	return data, nil
+}
+
+// InstallExtensionResult represents the result of webExtension.install.
+type InstallExtensionResult struct {
+	Extension string `json:"extension"`
+}
+
+// InstallExtension installs the unpacked extension in the directory at path
+// and returns the extension id.
+func (c *Client) InstallExtension(path string) (string, error) {
+	return c.InstallExtensionWithOpts(ExtensionData{Path: path})
+}
+
+// InstallExtensionWithOpts installs an extension from a directory, archive
+// file or archive contents and returns the extension id.
+func (c *Client) InstallExtensionWithOpts(ext ExtensionData) (string, error) {
+	data, err := ext.serialize()
+	if err != nil {
+		return "", err
+	}
+
+	params := map[string]interface{}{
+		"extensionData": data,
+	}
+
+	msg, err := c.SendCommand("webExtension.install", params)
+	if err != nil {
+		return "", err
+	}
+
+	var result InstallExtensionResult
+	if err := json.Unmarshal(msg.Result, &result); err != nil {
+		return "", fmt.Errorf("failed to parse webExtension.install result: %w", err)
+	}
+
+	return result.Extension, nil
+}
+
+// UninstallExtension removes an extension installed with InstallExtension.
+// The browser returns an error if the extension is not installed.
+func (c *Client) UninstallExtension(extensionID string) error {
+	if extensionID == "" {
+		return fmt.Errorf("extension id is required")
+	}
+
+	params := map[string]interface{}{
+		"extension": extensionID,
+	}
+
+	_, err := c.SendCommand("webExtension.uninstall", params)
+	return err
+}
@@ -62,3 +62,18 @@ func (e *BrowserCrashedError) Error() string {
 	}
 	return fmt.Sprintf("browser crashed with exit code %d", e.ExitCode)
 }
+
+// BiDiError is returned when the browser responds to a command with an error.
+// Code is the WebDriver BiDi error code, such as "no such frame".
+type BiDiError struct {
+	Code       string
+	Message    string
+	Stacktrace string
+}
+
+func (e *BiDiError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("BiDi error: %s - %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("BiDi error: %s", e.Code)
+}
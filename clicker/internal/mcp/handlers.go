@@ -112,7 +112,7 @@ func (h *Handlers) browserNavigate(args map[string]interface{}) (*ToolsCallResul
 		return nil, fmt.Errorf("url is required")
 	}
 
-	result, err := h.client.Navigate("", url)
+	result, err := h.client.Navigate("", url, bidi.ReadinessComplete)
 	if err != nil {
 		return nil, fmt.Errorf("failed to navigate: %w", err)
 	}
@@ -143,7 +143,7 @@ func (h *Handlers) browserClick(args map[string]interface{}) (*ToolsCallResult,
 	}
 
 	// Click the element
-	if err := h.client.ClickElement("", selector); err != nil {
+	if err := h.client.Click("", selector); err != nil {
 		return nil, fmt.Errorf("failed to click: %w", err)
 	}
 
@@ -178,7 +178,7 @@ func (h *Handlers) browserType(args map[string]interface{}) (*ToolsCallResult, e
 	}
 
 	// Type into the element
-	if err := h.client.TypeIntoElement("", selector, text); err != nil {
+	if err := h.client.Type("", selector, text); err != nil {
 		return nil, fmt.Errorf("failed to type: %w", err)
 	}
 
@@ -196,7 +196,7 @@ func (h *Handlers) browserScreenshot(args map[string]interface{}) (*ToolsCallRes
 		return nil, err
 	}
 
-	base64Data, err := h.client.CaptureScreenshot("")
+	pngData, err := h.client.CaptureScreenshot("", bidi.ScreenshotOpts{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to capture screenshot: %w", err)
 	}
@@ -216,10 +216,6 @@ func (h *Handlers) browserScreenshot(args map[string]interface{}) (*ToolsCallRes
 		safeName := filepath.Base(filename)
 		fullPath := filepath.Join(h.screenshotDir, safeName)
 
-		pngData, err := base64.StdEncoding.DecodeString(base64Data)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode screenshot: %w", err)
-		}
 		if err := os.WriteFile(fullPath, pngData, 0644); err != nil {
 			return nil, fmt.Errorf("failed to save screenshot: %w", err)
 		}
@@ -234,7 +230,7 @@ func (h *Handlers) browserScreenshot(args map[string]interface{}) (*ToolsCallRes
 	return &ToolsCallResult{
 		Content: []Content{{
 			Type:     "image",
-			Data:     base64Data,
+			Data:     base64.StdEncoding.EncodeToString(pngData),
 			MimeType: "image/png",
 		}},
 	}, nil
@@ -17,7 +17,6 @@ const defaultTimeout = 30 * time.Second
 type BrowserSession struct {
 	LaunchResult *browser.LaunchResult
 	BidiConn     *bidi.Connection
-	BidiClient   *bidi.Client
 	Client       *ClientConn
 	mu           sync.Mutex
 	closed       bool
@@ -88,13 +87,12 @@ func (r *Router) OnClientConnect(client *ClientConn) {
 
 	fmt.Printf("[router] BiDi connection established for client %d\n", client.ID)
 
-	// Create a BiDi client for handling custom commands
-	bidiClient := bidi.NewClient(bidiConn)
-
+	// routeBrowserToClient is the only reader of bidiConn, so vibium:
+	// commands are sent on it directly rather than through a bidi.Client,
+	// which would start a second reader
 	session := &BrowserSession{
 		LaunchResult:   launchResult,
 		BidiConn:       bidiConn,
-		BidiClient:     bidiClient,
 		Client:         client,
 		stopChan:       make(chan struct{}),
 		internalCmds:   make(map[int]chan json.RawMessage),